@@ -0,0 +1,275 @@
+package reqx
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenType        = "Bearer"
+	defaultTokenExpiryDelta = 10 * time.Second
+)
+
+var ErrNoToken = errors.New("reqx: token source returned no token")
+
+// Authenticator sets credentials on a request right before it is sent.
+type Authenticator interface {
+	Authenticate(req *RequestInfo) error
+}
+
+// ChallengeAuthenticator is an Authenticator that can react to an unauthorized
+// response. When Challenge returns true the request is authenticated again and replayed once.
+type ChallengeAuthenticator interface {
+	Authenticator
+	Challenge(req *RequestInfo, resp *ResponseInfo) (bool, error)
+}
+
+// Signer signs a fully built request. It runs after the Authenticator and OnBeforeRequest,
+// right before the request is sent, so it sees the final headers and body.
+type Signer interface {
+	Sign(req *RequestInfo) error
+}
+
+type SignerFunc func(req *RequestInfo) error
+
+func (f SignerFunc) Sign(req *RequestInfo) error {
+	return f(req)
+}
+
+type skipAuthenticationKey struct{}
+
+// WithoutAuthentication returns a context whose requests skip the Authenticator of the client,
+// e.g. token requests sent by the client that the token authenticates.
+func WithoutAuthentication(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, skipAuthenticationKey{}, true)
+}
+
+func skipAuthentication(ctx context.Context) bool {
+	return ctx != nil && ctx.Value(skipAuthenticationKey{}) != nil
+}
+
+type AuthenticatorFunc func(req *RequestInfo) error
+
+func (f AuthenticatorFunc) Authenticate(req *RequestInfo) error {
+	return f(req)
+}
+
+type basicAuth struct {
+	header string
+}
+
+func BasicAuth(username string, password string) Authenticator {
+	credentials := base64.StdEncoding.EncodeToString(toBytes(username + ":" + password))
+	return &basicAuth{
+		header: "Basic " + credentials,
+	}
+}
+
+func (a *basicAuth) Authenticate(req *RequestInfo) error {
+	req.Header.Set(HeaderAuthorization, a.header)
+	return nil
+}
+
+type bearerAuth struct {
+	header string
+}
+
+func BearerAuth(token string) Authenticator {
+	return &bearerAuth{
+		header: defaultTokenType + " " + token,
+	}
+}
+
+func (a *bearerAuth) Authenticate(req *RequestInfo) error {
+	req.Header.Set(HeaderAuthorization, a.header)
+	return nil
+}
+
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// Type returns the token type, defaulting to Bearer.
+func (t *Token) Type() string {
+	if t.TokenType == "" || strings.EqualFold(t.TokenType, defaultTokenType) {
+		return defaultTokenType
+	}
+	return t.TokenType
+}
+
+// AuthorizationHeader returns the value for the Authorization header.
+func (t *Token) AuthorizationHeader() string {
+	return t.Type() + " " + t.AccessToken
+}
+
+func (t *Token) expired(delta time.Duration) bool {
+	if t.Expiry.IsZero() {
+		return false
+	}
+	return time.Now().Add(delta).After(t.Expiry)
+}
+
+func (t *Token) valid(delta time.Duration) bool {
+	return t != nil && t.AccessToken != "" && !t.expired(delta)
+}
+
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// TokenRefresher is a TokenSource that can replace a token rejected with 401 Unauthorized.
+// Refresh returns a new token unless the current one already differs from staleAccessToken.
+type TokenRefresher interface {
+	TokenSource
+	Refresh(ctx context.Context, staleAccessToken string) (*Token, error)
+}
+
+type TokenSourceFunc func(ctx context.Context) (*Token, error)
+
+func (f TokenSourceFunc) Token(ctx context.Context) (*Token, error) {
+	return f(ctx)
+}
+
+// RefreshTokenSource caches the token returned by fetch and fetches a new one
+// shortly before it expires. Concurrent callers share a single fetch.
+type RefreshTokenSource struct {
+	fetch       TokenSourceFunc
+	expiryDelta time.Duration
+
+	mu         sync.Mutex
+	token      *Token
+	refreshing *tokenRefresh
+}
+
+type tokenRefresh struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// NewRefreshTokenSource returns a RefreshTokenSource. A non-positive expiryDelta uses the default of 10 seconds.
+func NewRefreshTokenSource(fetch TokenSourceFunc, expiryDelta time.Duration) *RefreshTokenSource {
+	if expiryDelta <= 0 {
+		expiryDelta = defaultTokenExpiryDelta
+	}
+	return &RefreshTokenSource{
+		fetch:       fetch,
+		expiryDelta: expiryDelta,
+	}
+}
+
+func (s *RefreshTokenSource) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	if s.token.valid(s.expiryDelta) {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	return s.refreshLocked(ctx)
+}
+
+// Refresh fetches a new token unless the cached one already differs from staleAccessToken,
+// which means another caller has refreshed it in the meantime.
+func (s *RefreshTokenSource) Refresh(ctx context.Context, staleAccessToken string) (*Token, error) {
+	s.mu.Lock()
+	if s.token.valid(s.expiryDelta) && s.token.AccessToken != staleAccessToken {
+		token := s.token
+		s.mu.Unlock()
+		return token, nil
+	}
+	return s.refreshLocked(ctx)
+}
+
+func (s *RefreshTokenSource) refreshLocked(ctx context.Context) (*Token, error) {
+	refresh := s.refreshing
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		s.refreshing = refresh
+		go s.doRefresh(context.WithoutCancel(ctx), refresh)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *RefreshTokenSource) doRefresh(ctx context.Context, refresh *tokenRefresh) {
+	token, err := s.fetch(ctx)
+	if err == nil && (token == nil || token.AccessToken == "") {
+		err = ErrNoToken
+	}
+
+	s.mu.Lock()
+	if err == nil {
+		s.token = token
+	}
+	s.refreshing = nil
+	s.mu.Unlock()
+
+	refresh.token, refresh.err = token, err
+	if err != nil {
+		refresh.token = nil
+	}
+	close(refresh.done)
+}
+
+type tokenAuth struct {
+	source TokenSource
+}
+
+// TokenAuth authenticates requests with tokens from source. When source is a TokenRefresher,
+// such as a RefreshTokenSource, a 401 response refreshes the token once and the request is replayed.
+func TokenAuth(source TokenSource) Authenticator {
+	return &tokenAuth{
+		source: source,
+	}
+}
+
+func (a *tokenAuth) Authenticate(req *RequestInfo) error {
+	token, err := a.source.Token(req.Context)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return ErrNoToken
+	}
+	req.Header.Set(HeaderAuthorization, token.AuthorizationHeader())
+	return nil
+}
+
+func (a *tokenAuth) Challenge(req *RequestInfo, resp *ResponseInfo) (bool, error) {
+	if resp.StatusCode() != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	refresher, ok := a.source.(TokenRefresher)
+	if !ok {
+		return false, nil
+	}
+
+	staleAccessToken := string(req.Header.Peek(HeaderAuthorization))
+	if _, accessToken, found := strings.Cut(staleAccessToken, " "); found {
+		staleAccessToken = accessToken
+	}
+
+	_, err := refresher.Refresh(req.Context, staleAccessToken)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,227 @@
+package reqx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func Test_BasicAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(w, ToJsonString(Response{Origin: "reqx"}))
+	}))
+	defer ts.Close()
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(reqx.BasicAuth("user", "pass")),
+	)
+
+	result := &Response{}
+	resp, err := client.Get(&reqx.Request{
+		URL:    ts.URL,
+		Result: result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Error("Test_BasicAuth Error")
+	}
+	if result.Origin != "reqx" {
+		t.Error("Test_BasicAuth Error")
+	}
+}
+
+func Test_BearerAuth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(reqx.HeaderAuthorization) != "Bearer 123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(reqx.BearerAuth("123456")),
+	)
+
+	resp, err := client.Get(&reqx.Request{
+		URL: ts.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Error("Test_BearerAuth Error")
+	}
+}
+
+func Test_TokenAuth_RefreshOnUnauthorized(t *testing.T) {
+	var validToken atomic.Value
+	validToken.Store("token-2")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(reqx.HeaderAuthorization) != "Bearer "+validToken.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprintln(w, ToJsonString(Response{Origin: "reqx"}))
+	}))
+	defer ts.Close()
+
+	var fetchCount atomic.Int32
+	source := reqx.NewRefreshTokenSource(func(ctx context.Context) (*reqx.Token, error) {
+		n := fetchCount.Add(1)
+		time.Sleep(20 * time.Millisecond)
+		return &reqx.Token{
+			AccessToken: fmt.Sprintf("token-%d", n),
+			Expiry:      time.Now().Add(time.Hour),
+		}, nil
+	}, 0)
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(reqx.TokenAuth(source)),
+	)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := &Response{}
+			resp, err := client.Get(&reqx.Request{
+				URL:    ts.URL,
+				Result: result,
+			})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if resp.StatusCode != http.StatusOK || result.Origin != "reqx" {
+				t.Errorf("Test_TokenAuth_RefreshOnUnauthorized Error: status %d", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fetchCount.Load() != 2 {
+		t.Errorf("Test_TokenAuth_RefreshOnUnauthorized Error: fetched %d tokens, want 2", fetchCount.Load())
+	}
+}
+
+func Test_RefreshTokenSource_Expiry(t *testing.T) {
+	var fetchCount atomic.Int32
+	source := reqx.NewRefreshTokenSource(func(ctx context.Context) (*reqx.Token, error) {
+		fetchCount.Add(1)
+		return &reqx.Token{
+			AccessToken: "token",
+			Expiry:      time.Now().Add(5 * time.Second),
+		}, nil
+	}, 10*time.Second)
+
+	for i := 0; i < 3; i++ {
+		_, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if fetchCount.Load() != 3 {
+		t.Errorf("Test_RefreshTokenSource_Expiry Error: fetched %d tokens, want 3", fetchCount.Load())
+	}
+}
+
+type rotatingTokenSource struct {
+	current   atomic.Value
+	refreshes atomic.Int32
+}
+
+func (s *rotatingTokenSource) Token(ctx context.Context) (*reqx.Token, error) {
+	return &reqx.Token{AccessToken: s.current.Load().(string)}, nil
+}
+
+func (s *rotatingTokenSource) Refresh(ctx context.Context, staleAccessToken string) (*reqx.Token, error) {
+	s.refreshes.Add(1)
+	s.current.Store("rotated")
+	return s.Token(ctx)
+}
+
+func Test_TokenAuth_TokenRefresher(t *testing.T) {
+	source := &rotatingTokenSource{}
+	source.current.Store("revoked")
+
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Request.Header.Peek(reqx.HeaderAuthorization)) != "Bearer rotated" {
+				ctx.SetStatusCode(http.StatusUnauthorized)
+			}
+		}),
+		reqx.WithAuthenticator(reqx.TokenAuth(source)),
+	)
+
+	resp, err := client.Get(&reqx.Request{URL: "http://api.local/me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || source.refreshes.Load() != 1 {
+		t.Errorf("Test_TokenAuth_TokenRefresher Error: status %d, refreshed %d times", resp.StatusCode, source.refreshes.Load())
+	}
+}
+
+type failingRefresher struct {
+	err error
+}
+
+func (s failingRefresher) Token(ctx context.Context) (*reqx.Token, error) {
+	return &reqx.Token{AccessToken: "revoked"}, nil
+}
+
+func (s failingRefresher) Refresh(ctx context.Context, staleAccessToken string) (*reqx.Token, error) {
+	return nil, s.err
+}
+
+func Test_TokenAuth_RefreshError(t *testing.T) {
+	refreshErr := errors.New("refresh token expired")
+	var completed, failed error
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(http.StatusUnauthorized)
+		}),
+		reqx.WithAuthenticator(reqx.TokenAuth(failingRefresher{err: refreshErr})),
+		reqx.WithOnRequestCompleted(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			completed = resp.Err
+		}),
+		reqx.WithOnRequestError(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			failed = resp.Err
+		}),
+	)
+
+	resp, err := client.Get(&reqx.Request{URL: "http://api.local/me"})
+	if !errors.Is(err, refreshErr) || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Test_TokenAuth_RefreshError Error: %+v, %v", resp, err)
+	}
+	if !errors.Is(completed, refreshErr) || !errors.Is(failed, refreshErr) {
+		t.Errorf("Test_TokenAuth_RefreshError Error: hooks %v, %v", completed, failed)
+	}
+}
@@ -0,0 +1,42 @@
+package cbor
+
+import (
+	fxcbor "github.com/fxamacker/cbor/v2"
+
+	"github.com/dreamph/reqx"
+)
+
+// ContentType is registered by WithCbor. Types with a +cbor suffix use the codec too.
+const ContentType = "application/cbor"
+
+// WithCbor registers the CBOR codec for application/cbor.
+func WithCbor() reqx.ClientOptions {
+	return reqx.WithCodec(NewCodec(), ContentType)
+}
+
+// Body is a request body of data sent as application/cbor.
+func Body(data interface{}) *reqx.Body {
+	return &reqx.Body{
+		ContentType: ContentType,
+		Data:        data,
+	}
+}
+
+type codec struct{}
+
+// NewCodec returns a CBOR Codec. Struct fields without a cbor tag use their json tag.
+func NewCodec() reqx.Codec {
+	return codec{}
+}
+
+func (codec) ContentType() string {
+	return ContentType
+}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return fxcbor.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return fxcbor.Unmarshal(data, v)
+}
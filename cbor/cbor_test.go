@@ -0,0 +1,50 @@
+package cbor_test
+
+import (
+	"testing"
+
+	fxcbor "github.com/fxamacker/cbor/v2"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/cbor"
+)
+
+type Reading struct {
+	Sensor string  `json:"sensor"`
+	Value  float64 `json:"value"`
+}
+
+func Test_Cbor(t *testing.T) {
+	client := reqx.New(
+		cbor.WithCbor(),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			var reading map[string]interface{}
+			err := fxcbor.Unmarshal(ctx.Request.Body(), &reading)
+			if err != nil {
+				t.Error(err)
+			}
+			if string(ctx.Request.Header.ContentType()) != cbor.ContentType || reading["sensor"] != "temp" {
+				t.Errorf("Test_Cbor Error: %s %v", ctx.Request.Header.ContentType(), reading)
+			}
+			reading["value"] = 21.5
+			body, err := fxcbor.Marshal(reading)
+			if err != nil {
+				t.Error(err)
+			}
+			ctx.SetContentType("application/senml+cbor")
+			ctx.SetBody(body)
+		}),
+	)
+
+	var result Reading
+	_, err := client.Post(&reqx.Request{
+		URL:     "http://gateway.local/readings",
+		Headers: reqx.Headers{reqx.HeaderContentType: cbor.ContentType},
+		Data:    &Reading{Sensor: "temp", Value: 20},
+		Result:  &result,
+	})
+	if err != nil || result != (Reading{Sensor: "temp", Value: 21.5}) {
+		t.Errorf("Test_Cbor Error: %+v, %v", result, err)
+	}
+}
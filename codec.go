@@ -0,0 +1,145 @@
+package reqx
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	gojson "github.com/goccy/go-json"
+)
+
+var ErrCodecNotFound = errors.New("reqx: codec not found")
+
+// Codec encodes request bodies and decodes response bodies of a content type.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Body is a request body encoded by the codec registered for ContentType.
+type Body struct {
+	ContentType string
+	Data        interface{}
+}
+
+// WithCodec registers codec for contentTypes, or for codec.ContentType() when none are given.
+// A codec registered for application/json or application/xml replaces the default one.
+func WithCodec(codec Codec, contentTypes ...string) ClientOptions {
+	return func(opts *ClientOption) {
+		if len(contentTypes) == 0 {
+			contentTypes = []string{codec.ContentType()}
+		}
+		if opts.Codecs == nil {
+			opts.Codecs = map[string]Codec{}
+		}
+		for _, contentType := range contentTypes {
+			opts.Codecs[contentType] = codec
+		}
+	}
+}
+
+type jsonCodec struct {
+	marshal   func(v interface{}) ([]byte, error)
+	unmarshal func(data []byte, v interface{}) error
+}
+
+// NewJsonCodec returns a Codec for application/json using marshal and unmarshal.
+func NewJsonCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) Codec {
+	return &jsonCodec{
+		marshal:   marshal,
+		unmarshal: unmarshal,
+	}
+}
+
+func (c *jsonCodec) ContentType() string {
+	return HeaderContentTypeJson
+}
+
+func (c *jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.marshal(v)
+}
+
+func (c *jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.unmarshal(data, v)
+}
+
+type codecs struct {
+	byContentType map[string]Codec
+	json          Codec
+}
+
+func newCodecs(opt *ClientOption) *codecs {
+	marshal, unmarshal := opt.JsonMarshal, opt.JsonUnmarshal
+	if marshal == nil {
+		marshal = gojson.Marshal
+	}
+	if unmarshal == nil {
+		unmarshal = gojson.Unmarshal
+	}
+
+	c := &codecs{byContentType: map[string]Codec{}}
+	c.register(HeaderContentTypeJson, NewJsonCodec(marshal, unmarshal))
+	c.register(HeaderContentTypeXml, NewXmlCodec())
+	c.register(HeaderContentTypeTextXml, NewXmlCodec())
+	for contentType, codec := range opt.Codecs {
+		c.register(contentType, codec)
+	}
+	c.json = c.byContentType[HeaderContentTypeJson]
+	return c
+}
+
+func (c *codecs) register(contentType string, codec Codec) {
+	c.byContentType[mediaType(contentType)] = codec
+}
+
+// lookup finds the codec of contentType by its media type, then by its structured syntax suffix,
+// so application/problem+json uses the application/json codec.
+func (c *codecs) lookup(contentType string) (Codec, bool) {
+	mt := mediaType(contentType)
+	if mt == "" {
+		return nil, false
+	}
+	if codec, ok := c.byContentType[mt]; ok {
+		return codec, true
+	}
+	if i := strings.LastIndexByte(mt, '+'); i >= 0 {
+		codec, ok := c.byContentType["application/"+mt[i+1:]]
+		return codec, ok
+	}
+	return nil, false
+}
+
+// request returns the codec for a request body with contentType, the JSON codec when it is empty.
+// When none is registered it returns the JSON codec, or ErrCodecNotFound if strict.
+func (c *codecs) request(contentType string, strict bool) (Codec, error) {
+	if contentType == "" {
+		return c.json, nil
+	}
+	codec, ok := c.lookup(contentType)
+	if !ok {
+		if strict {
+			return nil, fmt.Errorf("%w: %s", ErrCodecNotFound, contentType)
+		}
+		return c.json, nil
+	}
+	return codec, nil
+}
+
+// response returns the codec for a response body with contentType, the JSON codec when none is registered.
+func (c *codecs) response(contentType string) Codec {
+	codec, ok := c.lookup(contentType)
+	if !ok {
+		return c.json
+	}
+	return codec
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mt
+}
@@ -0,0 +1,144 @@
+package reqx_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string {
+	return "application/xml"
+}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+type Problem struct {
+	XMLName xml.Name `xml:"problem" json:"-"`
+	Title   string   `xml:"title" json:"title"`
+}
+
+func Test_Codecs_Response(t *testing.T) {
+	client := reqx.New(
+		reqx.WithCodec(xmlCodec{}, "application/xml", "text/xml"),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			switch string(ctx.Path()) {
+			case "/xml":
+				ctx.SetStatusCode(http.StatusBadRequest)
+				ctx.SetContentType("text/xml; charset=utf-8")
+				ctx.SetBodyString(`<problem><title>invalid</title></problem>`)
+			case "/problem":
+				ctx.SetStatusCode(http.StatusBadRequest)
+				ctx.SetContentType("application/problem+json")
+				ctx.SetBodyString(`{"title":"invalid"}`)
+			default:
+				ctx.SetContentType(reqx.HeaderContentTypeJson)
+				ctx.SetBodyString(`{"name":"reqx"}`)
+			}
+		}),
+	)
+
+	var result Data
+	_, err := client.Get(&reqx.Request{URL: "http://api.local/json", Result: &result})
+	if err != nil || result.Name != "reqx" {
+		t.Errorf("Test_Codecs_Response Error: %+v, %v", result, err)
+	}
+
+	for _, path := range []string{"/xml", "/problem"} {
+		var problem Problem
+		_, err = client.Get(&reqx.Request{URL: "http://api.local" + path, Result: &result, ErrorResult: &problem})
+		if err != nil || problem.Title != "invalid" {
+			t.Errorf("Test_Codecs_Response Error: %s %+v, %v", path, problem, err)
+		}
+	}
+}
+
+func Test_Codecs_Request(t *testing.T) {
+	var contentType, body string
+	client := reqx.New(
+		reqx.WithCodec(xmlCodec{}),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			contentType = string(ctx.Request.Header.ContentType())
+			body = string(ctx.Request.Body())
+		}),
+	)
+
+	tests := []struct {
+		name            string
+		request         *reqx.Request
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "default json",
+			request:         &reqx.Request{Data: &Problem{Title: "reqx"}},
+			wantContentType: reqx.HeaderContentTypeJson,
+			wantBody:        `{"title":"reqx"}`,
+		},
+		{
+			name:            "body wrapper",
+			request:         &reqx.Request{Data: reqx.Body{ContentType: "application/xml", Data: &Problem{Title: "reqx"}}},
+			wantContentType: "application/xml",
+			wantBody:        `<problem><title>reqx</title></problem>`,
+		},
+		{
+			name: "content type header",
+			request: &reqx.Request{
+				Headers: reqx.Headers{reqx.HeaderContentType: "application/vnd.reqx+json"},
+				Data:    &Problem{Title: "reqx"},
+			},
+			wantContentType: "application/vnd.reqx+json",
+			wantBody:        `{"title":"reqx"}`,
+		},
+		{
+			name: "unregistered vendor content type",
+			request: &reqx.Request{
+				Headers: reqx.Headers{reqx.HeaderContentType: "application/vnd.company.v2"},
+				Data:    &Problem{Title: "reqx"},
+			},
+			wantContentType: "application/vnd.company.v2",
+			wantBody:        `{"title":"reqx"}`,
+		},
+		{
+			name: "unregistered text content type",
+			request: &reqx.Request{
+				Headers: reqx.Headers{reqx.HeaderContentType: "text/plain"},
+				Data:    &Problem{Title: "reqx"},
+			},
+			wantContentType: "text/plain",
+			wantBody:        `{"title":"reqx"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.request.URL = "http://api.local/problems"
+			_, err := client.Post(tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if contentType != tt.wantContentType || body != tt.wantBody {
+				t.Errorf("Test_Codecs_Request Error: %s %s", contentType, body)
+			}
+		})
+	}
+
+	_, err := client.Post(&reqx.Request{
+		URL:  "http://api.local/problems",
+		Data: &reqx.Body{ContentType: "application/yaml", Data: &Problem{Title: "reqx"}},
+	})
+	if !errors.Is(err, reqx.ErrCodecNotFound) {
+		t.Errorf("Test_Codecs_Request Error: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package reqx
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	HeaderContentEncoding = "Content-Encoding"
+
+	ContentEncodingGzip   = "gzip"
+	ContentEncodingBrotli = "br"
+	ContentEncodingZstd   = "zstd"
+)
+
+// Compression compresses Raw and JSON request bodies.
+type Compression struct {
+	// Encoding is gzip, br or zstd. An empty encoding disables compression.
+	Encoding string
+	// MinSize is the smallest body size in bytes that is compressed.
+	MinSize int
+	// Level is the level of the encoder, 0 uses its default.
+	Level int
+}
+
+// WithCompression compresses Raw and JSON request bodies of at least minSize bytes with encoding.
+// Request.Compression overrides it per request.
+func WithCompression(encoding string, minSize int) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Compression = &Compression{
+			Encoding: encoding,
+			MinSize:  minSize,
+		}
+	}
+}
+
+var zstdEncoders sync.Map
+
+func (c *httpClient) compressBody(req *fasthttp.Request, request *Request) error {
+	compression := c.compression
+	if request.Compression != nil {
+		compression = request.Compression
+	}
+	if compression == nil || compression.Encoding == "" || len(req.Body()) == 0 || len(req.Body()) < compression.MinSize {
+		return nil
+	}
+	if len(req.Header.Peek(HeaderContentEncoding)) > 0 {
+		// The body is already encoded by the caller.
+		return nil
+	}
+
+	compressed, err := compress(compression.Encoding, compression.Level, req.Body())
+	if err != nil {
+		return err
+	}
+	req.SetBody(compressed)
+	req.Header.Set(HeaderContentEncoding, compression.Encoding)
+	return nil
+}
+
+func compress(encoding string, level int, data []byte) ([]byte, error) {
+	switch encoding {
+	case ContentEncodingGzip:
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		var buf bytes.Buffer
+		w, err := gzip.NewWriterLevel(&buf, level)
+		if err != nil {
+			return nil, err
+		}
+		_, err = w.Write(data)
+		if err != nil {
+			return nil, err
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ContentEncodingBrotli:
+		if level == 0 {
+			level = brotli.DefaultCompression
+		}
+		var buf bytes.Buffer
+		w := brotli.NewWriterLevel(&buf, level)
+		_, err := w.Write(data)
+		if err != nil {
+			return nil, err
+		}
+		err = w.Close()
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case ContentEncodingZstd:
+		encoder, err := zstdEncoder(level)
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+	}
+	return nil, fmt.Errorf("reqx: unsupported content encoding %q", encoding)
+}
+
+// zstdEncoder returns a shared encoder for level. EncodeAll is safe for concurrent use.
+func zstdEncoder(level int) (*zstd.Encoder, error) {
+	if encoder, ok := zstdEncoders.Load(level); ok {
+		return encoder.(*zstd.Encoder), nil
+	}
+
+	opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+	if level != 0 {
+		opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	}
+	encoder, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := zstdEncoders.LoadOrStore(level, encoder)
+	return actual.(*zstd.Encoder), nil
+}
@@ -0,0 +1,121 @@
+package reqx_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func decompress(encoding string, body []byte) ([]byte, error) {
+	switch encoding {
+	case reqx.ContentEncodingGzip:
+		r, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	case reqx.ContentEncodingBrotli:
+		return io.ReadAll(brotli.NewReader(bytes.NewReader(body)))
+	case reqx.ContentEncodingZstd:
+		r, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	return body, nil
+}
+
+func Test_WithCompression(t *testing.T) {
+	type received struct {
+		encoding string
+		body     string
+	}
+	var got received
+	handler := func(ctx *fasthttp.RequestCtx) {
+		encoding := string(ctx.Request.Header.Peek(reqx.HeaderContentEncoding))
+		body, err := decompress(encoding, ctx.Request.Body())
+		if err != nil {
+			t.Error(err)
+		}
+		got = received{encoding: encoding, body: string(body)}
+	}
+
+	large := strings.Repeat("reqx ", 100)
+	tests := []struct {
+		name    string
+		client  reqx.Client
+		request *reqx.Request
+		want    received
+	}{
+		{
+			name:    "gzip json",
+			client:  reqx.New(reqx.WithHandler(handler), reqx.WithCompression(reqx.ContentEncodingGzip, 100)),
+			request: &reqx.Request{Data: &Data{Name: large}},
+			want:    received{encoding: reqx.ContentEncodingGzip, body: `{"name":"` + large + `"}`},
+		},
+		{
+			name:    "br raw",
+			client:  reqx.New(reqx.WithHandler(handler), reqx.WithCompression(reqx.ContentEncodingBrotli, 100)),
+			request: &reqx.Request{Data: reqx.Raw{Body: []byte(large)}},
+			want:    received{encoding: reqx.ContentEncodingBrotli, body: large},
+		},
+		{
+			name:    "zstd per request",
+			client:  reqx.New(reqx.WithHandler(handler)),
+			request: &reqx.Request{Data: reqx.Raw{Body: []byte(large)}, Compression: &reqx.Compression{Encoding: reqx.ContentEncodingZstd, Level: 3}},
+			want:    received{encoding: reqx.ContentEncodingZstd, body: large},
+		},
+		{
+			name:    "below min size",
+			client:  reqx.New(reqx.WithHandler(handler), reqx.WithCompression(reqx.ContentEncodingGzip, 100)),
+			request: &reqx.Request{Data: reqx.Raw{Body: []byte("small")}},
+			want:    received{body: "small"},
+		},
+		{
+			name:    "disabled per request",
+			client:  reqx.New(reqx.WithHandler(handler), reqx.WithCompression(reqx.ContentEncodingGzip, 0)),
+			request: &reqx.Request{Data: reqx.Raw{Body: []byte(large)}, Compression: &reqx.Compression{}},
+			want:    received{body: large},
+		},
+		{
+			name:   "already encoded",
+			client: reqx.New(reqx.WithHandler(handler), reqx.WithCompression(reqx.ContentEncodingBrotli, 0)),
+			request: &reqx.Request{
+				Data:    reqx.Raw{Body: []byte("identity")},
+				Headers: reqx.Headers{reqx.HeaderContentEncoding: "identity"},
+			},
+			want: received{encoding: "identity", body: "identity"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = received{}
+			tt.request.URL = "http://api.local/upload"
+			_, err := tt.client.Post(tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Test_WithCompression Error: got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := reqx.New(reqx.WithHandler(handler), reqx.WithCompression("lz4", 0)).Post(&reqx.Request{
+		URL:  "http://api.local/upload",
+		Data: reqx.Raw{Body: []byte(large)},
+	})
+	if err == nil {
+		t.Error("Test_WithCompression Error: expected unsupported encoding error")
+	}
+}
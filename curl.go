@@ -0,0 +1,277 @@
+package reqx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valyala/fasthttp"
+)
+
+const CurlMask = "****"
+
+// DefaultCurlMaskHeaders are masked by WithCurlMask when no headers are given.
+var DefaultCurlMaskHeaders = []string{
+	HeaderAuthorization,
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Api-Key",
+}
+
+type CurlOption struct {
+	MaskHeaders []string
+}
+
+type CurlOptions func(opts *CurlOption)
+
+// WithCurlMask replaces the values of headers with CurlMask. Without headers DefaultCurlMaskHeaders are masked.
+func WithCurlMask(headers ...string) CurlOptions {
+	return func(opts *CurlOption) {
+		if len(headers) == 0 {
+			headers = DefaultCurlMaskHeaders
+		}
+		opts.MaskHeaders = append(opts.MaskHeaders, headers...)
+	}
+}
+
+// Curl returns a curl command that sends the same request.
+func (r *RequestInfo) Curl(opts ...CurlOptions) string {
+	opt := &CurlOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	return curlCommand(r.Request, opt)
+}
+
+// CurlClient is implemented by clients that build curl commands, such as the clients of New.
+// Wrappers of a Client implement it to support Curl.
+type CurlClient interface {
+	Curl(method string, request *Request, opts ...CurlOptions) (string, error)
+}
+
+// Curl builds request as client would send it, before authentication and signing, and returns
+// the equivalent curl command. Readers of file params are consumed.
+// It returns ErrUnsupportedClient when client does not implement CurlClient.
+func Curl(client Client, method string, request *Request, opts ...CurlOptions) (string, error) {
+	c, ok := client.(CurlClient)
+	if !ok {
+		return "", ErrUnsupportedClient
+	}
+	return c.Curl(method, request, opts...)
+}
+
+func (c *httpClient) Curl(method string, request *Request, opts ...CurlOptions) (string, error) {
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+	}()
+
+	err := c.initRequest(req, resp, request, method)
+	if err != nil {
+		return "", err
+	}
+	return (&RequestInfo{Request: req, Context: request.Context}).Curl(opts...), nil
+}
+
+func curlCommand(req *fasthttp.Request, opt *CurlOption) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X ")
+	sb.WriteString(string(req.Header.Method()))
+	sb.WriteString(" ")
+	sb.WriteString(shellQuote(req.URI().String()))
+
+	body, decoded := curlBody(req)
+	mediaType, params, _ := mime.ParseMediaType(string(req.Header.ContentType()))
+	isMultipart := len(body) > 0 && strings.HasPrefix(mediaType, "multipart/")
+	isFormUrlEncoded := len(body) > 0 && mediaType == HeaderContentTypeFormUrlEncoded
+
+	req.Header.VisitAll(func(key, value []byte) {
+		name := string(key)
+		switch {
+		case strings.EqualFold(name, fasthttp.HeaderHost), strings.EqualFold(name, fasthttp.HeaderContentLength):
+			return
+		case strings.EqualFold(name, HeaderContentType) && (isMultipart || isFormUrlEncoded && bytes.Equal(value, HeaderContentTypeFormUrlEncodedBytes)):
+			// curl sets these content types itself.
+			return
+		case strings.EqualFold(name, HeaderAcceptEncoding) && string(value) == DefaultAcceptEncoding:
+			// Without it curl prints the decoded body, as reqx returns it.
+			return
+		case strings.EqualFold(name, HeaderContentEncoding) && decoded:
+			// The command sends the body uncompressed.
+			return
+		}
+
+		v := string(value)
+		if slices.ContainsFunc(opt.MaskHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			v = CurlMask
+		}
+		sb.WriteString(" \\\n  -H ")
+		sb.WriteString(shellQuote(name + ": " + v))
+	})
+
+	switch {
+	case len(body) == 0:
+	case isMultipart:
+		fields, err := curlMultipartFields(body, params["boundary"])
+		if err != nil {
+			writeCurlData(&sb, "--data-binary", string(body))
+			break
+		}
+		for _, field := range fields {
+			writeCurlData(&sb, field.flag, field.value)
+		}
+	case isFormUrlEncoded:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			writeCurlData(&sb, "--data-raw", string(body))
+			break
+		}
+		for _, key := range sortedKeys(values) {
+			for _, v := range values[key] {
+				writeCurlData(&sb, "--data-urlencode", key+"="+v)
+			}
+		}
+	case !utf8.Valid(body):
+		// Arguments cannot hold every byte, the body is piped from printf.
+		sb.WriteString(" \\\n  --data-binary @-")
+		return "printf " + printfQuote(body) + " | " + sb.String()
+	default:
+		writeCurlData(&sb, "--data-raw", string(body))
+	}
+	return sb.String()
+}
+
+// curlBody returns the body of req, decompressed when it was compressed with a known encoding.
+func curlBody(req *fasthttp.Request) ([]byte, bool) {
+	body := req.Body()
+	encoding := strings.TrimSpace(string(req.Header.Peek(HeaderContentEncoding)))
+	switch encoding {
+	case ContentEncodingGzip, ContentEncodingDeflate, ContentEncodingBrotli, ContentEncodingZstd:
+	default:
+		return body, false
+	}
+	if len(body) == 0 {
+		return body, false
+	}
+	decompressed, err := decompress(encoding, body, DefaultMaxDecompressedSize)
+	if err != nil {
+		return body, false
+	}
+	return decompressed, true
+}
+
+// printfQuote returns a quoted printf format that prints data.
+func printfQuote(data []byte) string {
+	const hexDigits = "0123456789abcdef"
+
+	var sb strings.Builder
+	for _, c := range data {
+		switch {
+		case c == '%':
+			sb.WriteString("%%")
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c >= 0x20 && c < 0x7f:
+			sb.WriteByte(c)
+		default:
+			sb.WriteString(`\x`)
+			sb.WriteByte(hexDigits[c>>4])
+			sb.WriteByte(hexDigits[c&15])
+		}
+	}
+	return shellQuote(sb.String())
+}
+
+func writeCurlData(sb *strings.Builder, flag string, data string) {
+	sb.WriteString(" \\\n  ")
+	sb.WriteString(flag)
+	sb.WriteString(" ")
+	sb.WriteString(shellQuote(data))
+}
+
+type curlField struct {
+	flag  string
+	value string
+}
+
+// curlMultipartFields returns -F values. File parts refer to their file name, the content is not included.
+// Values curl would interpret as files or parameters are sent with --form-string.
+func curlMultipartFields(body []byte, boundary string) ([]curlField, error) {
+	var fields []curlField
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return fields, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if fileName := part.FileName(); fileName != "" {
+			fields = append(fields, curlField{flag: "-F", value: part.FormName() + "=@" + fileName})
+			continue
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		flag := "-F"
+		if bytes.HasPrefix(data, []byte("@")) || bytes.HasPrefix(data, []byte("<")) || bytes.ContainsRune(data, ';') {
+			flag = "--form-string"
+		}
+		fields = append(fields, curlField{flag: flag, value: part.FormName() + "=" + string(data)})
+	}
+}
+
+func sortedKeys(values url.Values) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// debugOnRequestError logs the curl command of failed requests with secrets masked before calling next.
+// It logs with logger when set, at the levels of the log transport, and with the log package otherwise.
+func debugOnRequestError(next OnRequestError, logger *slog.Logger) OnRequestError {
+	return func(req *RequestInfo, resp *ResponseInfo) {
+		curl := req.Curl(WithCurlMask())
+		switch {
+		case logger != nil:
+			ctx := req.Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if resp.Err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "reqx: request failed", slog.String("error", resp.Err.Error()), slog.String("curl", curl))
+			} else {
+				logger.LogAttrs(ctx, slog.LevelWarn, "reqx: request failed", slog.Int("status", resp.StatusCode()), slog.String("curl", curl))
+			}
+		case resp.Err != nil:
+			log.Printf("reqx: request failed: %v\n%s", resp.Err, curl)
+		default:
+			log.Printf("reqx: request failed with status %d\n%s", resp.StatusCode(), curl)
+		}
+		if next != nil {
+			next(req, resp)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package reqx_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/reqxtest"
+)
+
+func Test_Curl(t *testing.T) {
+	client := reqx.New(
+		reqx.WithBaseURL("https://api.example.com/v1"),
+		reqx.WithHeaders(reqx.Headers{reqx.HeaderAuthorization: "Bearer secret"}),
+	)
+
+	values := url.Values{"name": {"reqx"}, "note": {"it's & done"}}
+	tests := []struct {
+		name    string
+		request *reqx.Request
+		opts    []reqx.CurlOptions
+		want    string
+	}{
+		{
+			name: "json",
+			request: &reqx.Request{
+				URL:     "/users?id=1",
+				Headers: reqx.Headers{"X-Request-Id": "1"},
+				Data:    &Data{Name: "it's reqx"},
+			},
+			opts: []reqx.CurlOptions{reqx.WithCurlMask()},
+			want: `curl -X POST 'https://api.example.com/v1/users?id=1' \
+  -H 'Content-Type: application/json' \
+  -H 'Authorization: ****' \
+  -H 'X-Request-Id: 1' \
+  --data-raw '{"name":"it'\''s reqx"}'`,
+		},
+		{
+			name: "form url encoded",
+			request: &reqx.Request{
+				URL:  "/users",
+				Data: &reqx.FormUrlEncoded{Values: &values},
+			},
+			want: `curl -X POST 'https://api.example.com/v1/users' \
+  -H 'Authorization: Bearer secret' \
+  --data-urlencode 'name=reqx' \
+  --data-urlencode 'note=it'\''s & done'`,
+		},
+		{
+			name: "multipart",
+			request: &reqx.Request{
+				URL: "/upload",
+				Data: &reqx.Form{
+					FormData: reqx.FormData{"firstName": "reqx"},
+					Files: reqx.WithFileParams(
+						reqx.WithFileParam("file1", "test1.txt", bytes.NewReader([]byte("file content"))),
+					),
+				},
+			},
+			opts: []reqx.CurlOptions{reqx.WithCurlMask("X-Other")},
+			want: `curl -X POST 'https://api.example.com/v1/upload' \
+  -H 'Authorization: Bearer secret' \
+  -F 'firstName=reqx' \
+  -F 'file1=@test1.txt'`,
+		},
+		{
+			name: "compressed",
+			request: &reqx.Request{
+				URL:         "/users",
+				Data:        &Data{Name: "reqx"},
+				Compression: &reqx.Compression{Encoding: reqx.ContentEncodingGzip},
+			},
+			want: `curl -X POST 'https://api.example.com/v1/users' \
+  -H 'Content-Type: application/json' \
+  -H 'Authorization: Bearer secret' \
+  --data-raw '{"name":"reqx"}'`,
+		},
+		{
+			name: "binary",
+			request: &reqx.Request{
+				URL:  "/blobs",
+				Data: []byte{0x1f, 0x8b, 0x00, '%', '\\', '\''},
+			},
+			want: `printf '\x1f\x8b\x00%%\\'\''' | curl -X POST 'https://api.example.com/v1/blobs' \
+  -H 'Authorization: Bearer secret' \
+  --data-binary @-`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reqx.Curl(client, http.MethodPost, tt.request, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Curl() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// curlClient wraps a Client and forwards Curl.
+type curlClient struct {
+	reqx.Client
+}
+
+func (c curlClient) Curl(method string, request *reqx.Request, opts ...reqx.CurlOptions) (string, error) {
+	return reqx.Curl(c.Client, method, request, opts...)
+}
+
+func Test_Curl_Client(t *testing.T) {
+	request := &reqx.Request{URL: "http://api.local/users"}
+	_, err := reqx.Curl(struct{ reqx.Client }{reqx.New()}, http.MethodGet, request)
+	if !errors.Is(err, reqx.ErrUnsupportedClient) {
+		t.Errorf("Test_Curl_Client Error: %v", err)
+	}
+
+	got, err := reqx.Curl(curlClient{reqx.New()}, http.MethodGet, request)
+	if err != nil || got != `curl -X GET 'http://api.local/users'` {
+		t.Errorf("Test_Curl_Client Error: %s, %v", got, err)
+	}
+}
+
+func Test_Debug(t *testing.T) {
+	var buf bytes.Buffer
+	writer := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(writer)
+
+	mock := reqxtest.NewMockTransport()
+	mock.On(http.MethodGet, "*").ReplyString(http.StatusInternalServerError, "boom")
+
+	called := false
+	client := reqxtest.NewClient(mock,
+		reqx.WithDebug(true),
+		reqx.WithHeaders(reqx.Headers{reqx.HeaderAuthorization: "Bearer secret"}),
+		reqx.WithOnRequestError(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			called = true
+		}),
+	)
+
+	_, err := client.Get(&reqx.Request{URL: "http://localhost/users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := buf.String()
+	if !called || !strings.Contains(output, "status 500") || !strings.Contains(output, `curl -X GET 'http://localhost/users'`) || strings.Contains(output, "secret") {
+		t.Errorf("Test_Debug Error: %v %s", called, output)
+	}
+}
+
+func Test_Debug_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	writer := log.Writer()
+	log.SetOutput(&buf)
+	defer log.SetOutput(writer)
+
+	var logs bytes.Buffer
+	mock := reqxtest.NewMockTransport()
+	mock.On(http.MethodGet, "*").ReplyString(http.StatusInternalServerError, "boom")
+	client := reqxtest.NewClient(mock,
+		reqx.WithDebug(true),
+		reqx.WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))),
+	)
+
+	_, err := client.Get(&reqx.Request{URL: "http://localhost/users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 || !strings.Contains(logs.String(), `"msg":"reqx: request failed","status":500,"curl":"curl -X GET 'http://localhost/users'"`) {
+		t.Errorf("Test_Debug_Logger Error: %s %s", buf.String(), logs.String())
+	}
+}
@@ -0,0 +1,176 @@
+package reqx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	HeaderAcceptEncoding = "Accept-Encoding"
+
+	ContentEncodingDeflate  = "deflate"
+	ContentEncodingIdentity = "identity"
+
+	// DefaultAcceptEncoding is sent unless the request sets Accept-Encoding.
+	DefaultAcceptEncoding = "gzip, deflate, br, zstd"
+	// DefaultMaxDecompressedSize limits decompressed response bodies to 32 MiB.
+	DefaultMaxDecompressedSize = 32 << 20
+)
+
+var ErrDecompressedBodyTooLarge = errors.New("reqx: decompressed response body too large")
+
+// WithDisableDecompression stops sending Accept-Encoding and decoding responses, so Result gets the raw body.
+func WithDisableDecompression(disable bool) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.DisableDecompression = disable
+	}
+}
+
+// WithMaxDecompressedSize limits the size of decompressed response bodies. A size of 0 uses DefaultMaxDecompressedSize.
+func WithMaxDecompressedSize(size int) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.MaxDecompressedSize = size
+	}
+}
+
+func (c *httpClient) setAcceptEncoding(req *fasthttp.Request) {
+	if c.disableDecompression || len(req.Header.Peek(HeaderAcceptEncoding)) > 0 {
+		return
+	}
+	req.Header.Set(HeaderAcceptEncoding, DefaultAcceptEncoding)
+}
+
+// decompressResponse replaces a compressed body by the decoded one and removes Content-Encoding.
+// Unknown encodings are left as they are.
+func (c *httpClient) decompressResponse(resp *fasthttp.Response) error {
+	if c.disableDecompression || len(resp.Body()) == 0 {
+		return nil
+	}
+	encodings, ok := contentEncodings(resp)
+	if !ok {
+		return nil
+	}
+
+	maxSize := c.maxDecompressedSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxDecompressedSize
+	}
+
+	body := resp.Body()
+	for _, encoding := range encodings {
+		var err error
+		body, err = decompress(encoding, body, maxSize)
+		if err != nil {
+			return err
+		}
+	}
+
+	resp.SetBody(body)
+	resp.Header.Del(HeaderContentEncoding)
+	return nil
+}
+
+// contentEncodings returns the encodings of resp in the order to decode them.
+// It reports false when there are none or one is unknown.
+func contentEncodings(resp *fasthttp.Response) ([]string, bool) {
+	return parseContentEncodings(string(resp.Header.Peek(HeaderContentEncoding)))
+}
+
+// parseContentEncodings is contentEncodings for the Content-Encoding header value contentEncoding.
+func parseContentEncodings(contentEncoding string) ([]string, bool) {
+	if contentEncoding == "" {
+		return nil, false
+	}
+
+	encodings := strings.Split(contentEncoding, ",")
+	for i := range encodings {
+		encodings[i] = strings.ToLower(strings.TrimSpace(encodings[i]))
+		switch encodings[i] {
+		case ContentEncodingGzip, ContentEncodingDeflate, ContentEncodingBrotli, ContentEncodingZstd, ContentEncodingIdentity, "":
+		default:
+			return nil, false
+		}
+	}
+	// Encodings are listed in the order they were applied.
+	slices.Reverse(encodings)
+	return encodings, true
+}
+
+func decompress(encoding string, data []byte, maxSize int) ([]byte, error) {
+	r, err := newDecompressReader(encoding, bytes.NewReader(data), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var buf bytes.Buffer
+	n, err := buf.ReadFrom(io.LimitReader(r, int64(maxSize)+1))
+	if errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		return nil, ErrDecompressedBodyTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reqx: decompress %s: %w", encoding, err)
+	}
+	if n > int64(maxSize) {
+		return nil, ErrDecompressedBodyTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeBody decodes body with the encodings of the Content-Encoding header contentEncoding.
+func decodeBody(contentEncoding string, body []byte, maxSize int) ([]byte, error) {
+	encodings, ok := parseContentEncodings(contentEncoding)
+	if !ok {
+		return body, nil
+	}
+	for _, encoding := range encodings {
+		var err error
+		body, err = decompress(encoding, body, maxSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// newDecompressReader decodes r with encoding, unknown encodings are read as they are.
+// A maxMemory above 0 limits the memory of the zstd decoder.
+func newDecompressReader(encoding string, r io.Reader, maxMemory int) (io.ReadCloser, error) {
+	switch encoding {
+	case ContentEncodingGzip:
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reqx: decompress gzip: %w", err)
+		}
+		return gr, nil
+	case ContentEncodingDeflate:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reqx: decompress deflate: %w", err)
+		}
+		return zr, nil
+	case ContentEncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case ContentEncodingZstd:
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if maxMemory > 0 {
+			opts = append(opts, zstd.WithDecoderMaxMemory(uint64(maxMemory)))
+		}
+		zr, err := zstd.NewReader(r, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("reqx: decompress zstd: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
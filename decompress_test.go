@@ -0,0 +1,125 @@
+package reqx_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func compressed(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch encoding {
+	case reqx.ContentEncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case reqx.ContentEncodingDeflate:
+		w = zlib.NewWriter(&buf)
+	case reqx.ContentEncodingBrotli:
+		w = brotli.NewWriter(&buf)
+	case reqx.ContentEncodingZstd:
+		w, err = zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = w.Write(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_Decompression(t *testing.T) {
+	body := []byte(`{"name":"` + strings.Repeat("reqx", 100) + `"}`)
+	var acceptEncoding string
+	handler := func(ctx *fasthttp.RequestCtx) {
+		acceptEncoding = string(ctx.Request.Header.Peek(reqx.HeaderAcceptEncoding))
+		encoding := string(ctx.QueryArgs().Peek("encoding"))
+		ctx.SetContentType(reqx.HeaderContentTypeJson)
+		ctx.Response.Header.Set(reqx.HeaderContentEncoding, encoding)
+		ctx.SetBody(compressed(t, encoding, body))
+	}
+
+	client := reqx.New(reqx.WithHandler(handler))
+	for _, encoding := range []string{reqx.ContentEncodingGzip, reqx.ContentEncodingDeflate, reqx.ContentEncodingBrotli, reqx.ContentEncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			var result Data
+			resp, err := client.Get(&reqx.Request{
+				URL:    "http://api.local/users?encoding=" + encoding,
+				Result: &result,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Name != strings.Repeat("reqx", 100) || resp.Headers[reqx.HeaderContentEncoding] != "" {
+				t.Errorf("Test_Decompression Error: %q, headers %v", result.Name, resp.Headers)
+			}
+			if acceptEncoding != reqx.DefaultAcceptEncoding {
+				t.Errorf("Test_Decompression Error: Accept-Encoding %q", acceptEncoding)
+			}
+		})
+	}
+
+	var result Data
+	_, err := client.Get(&reqx.Request{
+		URL:     "http://api.local/users?encoding=gzip",
+		Headers: reqx.Headers{reqx.HeaderAcceptEncoding: reqx.ContentEncodingGzip},
+		Result:  &result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptEncoding != reqx.ContentEncodingGzip || result.Name == "" {
+		t.Errorf("Test_Decompression Error: Accept-Encoding %q, %q", acceptEncoding, result.Name)
+	}
+
+	var raw []byte
+	resp, err := reqx.New(reqx.WithHandler(handler), reqx.WithDisableDecompression(true)).Get(&reqx.Request{
+		URL:    "http://api.local/users?encoding=br",
+		Result: &raw,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acceptEncoding != "" || resp.Headers[reqx.HeaderContentEncoding] != reqx.ContentEncodingBrotli || !bytes.Equal(raw, compressed(t, reqx.ContentEncodingBrotli, body)) {
+		t.Errorf("Test_Decompression Error: Accept-Encoding %q, headers %v", acceptEncoding, resp.Headers)
+	}
+}
+
+func Test_MaxDecompressedSize(t *testing.T) {
+	bomb := bytes.Repeat([]byte("0"), 1<<20)
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			encoding := string(ctx.QueryArgs().Peek("encoding"))
+			ctx.Response.Header.Set(reqx.HeaderContentEncoding, encoding)
+			ctx.SetBody(compressed(t, encoding, bomb))
+		}),
+		reqx.WithMaxDecompressedSize(1<<10),
+	)
+
+	for _, encoding := range []string{reqx.ContentEncodingGzip, reqx.ContentEncodingZstd} {
+		var result []byte
+		_, err := client.Get(&reqx.Request{
+			URL:    "http://api.local/bomb?encoding=" + encoding,
+			Result: &result,
+		})
+		if !errors.Is(err, reqx.ErrDecompressedBodyTooLarge) {
+			t.Errorf("Test_MaxDecompressedSize Error: %s %v", encoding, err)
+		}
+	}
+}
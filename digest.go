@@ -0,0 +1,322 @@
+package reqx
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	digestAlgorithmMD5        = "MD5"
+	digestAlgorithmSHA256     = "SHA-256"
+	digestAlgorithmSHA512256  = "SHA-512-256"
+	digestSessionSuffix       = "-SESS"
+	digestQopAuth             = "auth"
+	digestQopAuthInt          = "auth-int"
+	digestChallengePrefix     = "digest "
+	digestAuthorizationPrefix = "Digest "
+)
+
+// digestAlgorithmPreference orders the supported algorithms from weakest to strongest.
+var digestAlgorithmPreference = []string{
+	digestAlgorithmMD5,
+	digestAlgorithmSHA256,
+	digestAlgorithmSHA512256,
+}
+
+type digestChallenge struct {
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	nc        uint32
+}
+
+type digestAuth struct {
+	username string
+	password string
+
+	mu         sync.Mutex
+	challenges map[string]*digestChallenge
+}
+
+// DigestAuth authenticates with HTTP Digest authentication (RFC 7616). The first request to a host
+// is sent without credentials; the 401 challenge is answered by replaying the request, and later
+// requests reuse the challenge with an increasing nonce count.
+func DigestAuth(username string, password string) Authenticator {
+	return &digestAuth{
+		username:   username,
+		password:   password,
+		challenges: map[string]*digestChallenge{},
+	}
+}
+
+func (a *digestAuth) Authenticate(req *RequestInfo) error {
+	host := string(req.Host())
+
+	a.mu.Lock()
+	challenge, ok := a.challenges[host]
+	if !ok {
+		a.mu.Unlock()
+		return nil
+	}
+	challenge.nc++
+	c := *challenge
+	a.mu.Unlock()
+
+	authorization, err := a.authorization(req, &c)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(HeaderAuthorization, authorization)
+	return nil
+}
+
+func (a *digestAuth) Challenge(req *RequestInfo, resp *ResponseInfo) (bool, error) {
+	if resp.StatusCode() != http.StatusUnauthorized {
+		return false, nil
+	}
+
+	challenge := parseDigestChallenges(resp)
+	if challenge == nil {
+		return false, nil
+	}
+
+	host := string(req.Host())
+	sent := strings.HasPrefix(string(req.Header.Peek(HeaderAuthorization)), digestAuthorizationPrefix)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	previous, ok := a.challenges[host]
+	if sent && ok && previous.nonce == challenge.nonce && !challenge.stale {
+		// The credentials were rejected for a valid nonce.
+		return false, nil
+	}
+
+	a.challenges[host] = &challenge.digestChallenge
+	return true, nil
+}
+
+func (a *digestAuth) authorization(req *RequestInfo, c *digestChallenge) (string, error) {
+	newHash, ok := digestHash(c.algorithm)
+	if !ok {
+		return "", fmt.Errorf("reqx: unsupported digest algorithm %q", c.algorithm)
+	}
+	h := func(s string) string {
+		hh := newHash()
+		hh.Write(toBytes(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	}
+
+	uri := string(req.URI().PathOriginal())
+	if uri == "" {
+		uri = "/"
+	}
+	if query := req.URI().QueryString(); len(query) > 0 {
+		uri += "?" + string(query)
+	}
+
+	cnonce, err := newCnonce()
+	if err != nil {
+		return "", err
+	}
+	nc := fmt.Sprintf("%08x", c.nc)
+
+	ha1 := h(a.username + ":" + c.realm + ":" + a.password)
+	if strings.HasSuffix(strings.ToUpper(c.algorithm), digestSessionSuffix) {
+		ha1 = h(ha1 + ":" + c.nonce + ":" + cnonce)
+	}
+
+	ha2 := h(string(req.Header.Method()) + ":" + uri)
+	if c.qop == digestQopAuthInt {
+		bodyHash := newHash()
+		bodyHash.Write(req.Body())
+		ha2 = h(string(req.Header.Method()) + ":" + uri + ":" + hex.EncodeToString(bodyHash.Sum(nil)))
+	}
+
+	var response string
+	if c.qop == "" {
+		response = h(ha1 + ":" + c.nonce + ":" + ha2)
+	} else {
+		response = h(ha1 + ":" + c.nonce + ":" + nc + ":" + cnonce + ":" + c.qop + ":" + ha2)
+	}
+
+	username := a.username
+	if c.userhash {
+		username = h(a.username + ":" + c.realm)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(digestAuthorizationPrefix)
+	sb.WriteString(`username=` + quoteDigest(username))
+	sb.WriteString(`, realm=` + quoteDigest(c.realm))
+	sb.WriteString(`, nonce=` + quoteDigest(c.nonce))
+	sb.WriteString(`, uri=` + quoteDigest(uri))
+	sb.WriteString(`, algorithm=` + c.algorithm)
+	sb.WriteString(`, response="` + response + `"`)
+	if c.opaque != "" {
+		sb.WriteString(`, opaque=` + quoteDigest(c.opaque))
+	}
+	if c.qop != "" {
+		sb.WriteString(`, qop=` + c.qop)
+		sb.WriteString(`, nc=` + nc)
+		sb.WriteString(`, cnonce="` + cnonce + `"`)
+	}
+	if c.userhash {
+		sb.WriteString(`, userhash=true`)
+	}
+	return sb.String(), nil
+}
+
+func digestHash(algorithm string) (func() hash.Hash, bool) {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), digestSessionSuffix) {
+	case digestAlgorithmMD5:
+		return md5.New, true
+	case digestAlgorithmSHA256:
+		return sha256.New, true
+	case digestAlgorithmSHA512256:
+		return sha512.New512_256, true
+	}
+	return nil, false
+}
+
+func newCnonce() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type parsedDigestChallenge struct {
+	digestChallenge
+	stale bool
+}
+
+// parseDigestChallenges returns the Digest challenge with the strongest supported algorithm.
+func parseDigestChallenges(resp *ResponseInfo) *parsedDigestChallenge {
+	var best *parsedDigestChallenge
+	bestRank := -1
+
+	resp.Header.VisitAll(func(key, value []byte) {
+		if !strings.EqualFold(string(key), HeaderWWWAuthenticate) {
+			return
+		}
+		challenge := parseDigestChallenge(string(value))
+		if challenge == nil {
+			return
+		}
+		rank := digestAlgorithmRank(challenge.algorithm)
+		if rank > bestRank {
+			best, bestRank = challenge, rank
+		}
+	})
+	return best
+}
+
+func digestAlgorithmRank(algorithm string) int {
+	name := strings.TrimSuffix(strings.ToUpper(algorithm), digestSessionSuffix)
+	for i, supported := range digestAlgorithmPreference {
+		if name == supported {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseDigestChallenge(value string) *parsedDigestChallenge {
+	value = strings.TrimSpace(value)
+	if len(value) < len(digestChallengePrefix) || !strings.EqualFold(value[:len(digestChallengePrefix)], digestChallengePrefix) {
+		return nil
+	}
+
+	params := parseAuthParams(value[len(digestChallengePrefix):])
+	if params["nonce"] == "" {
+		return nil
+	}
+
+	challenge := &parsedDigestChallenge{
+		digestChallenge: digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: params["algorithm"],
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+		},
+		stale: strings.EqualFold(params["stale"], "true"),
+	}
+	if challenge.algorithm == "" {
+		challenge.algorithm = digestAlgorithmMD5
+	}
+	if digestAlgorithmRank(challenge.algorithm) < 0 {
+		return nil
+	}
+
+	for _, qop := range strings.Split(params["qop"], ",") {
+		qop = strings.TrimSpace(qop)
+		if qop == digestQopAuth {
+			challenge.qop = digestQopAuth
+			break
+		}
+		if qop == digestQopAuthInt {
+			challenge.qop = digestQopAuthInt
+		}
+	}
+	return challenge
+}
+
+// quoteDigest returns s as a quoted string, escaping " and \ as RFC 7616 requires.
+func quoteDigest(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// parseAuthParams parses comma separated key=value pairs where values may be quoted strings.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " \t,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimLeft(s[eq+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var sb strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				sb.WriteByte(s[i])
+			}
+			value = sb.String()
+			if i < len(s) {
+				i++
+			}
+			s = s[i:]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
@@ -0,0 +1,199 @@
+package reqx_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dreamph/reqx"
+)
+
+var digestParamRegexp = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^,\s]+))`)
+
+type digestServer struct {
+	algorithm string
+	nonce     string
+
+	mu         sync.Mutex
+	lastNc     string
+	nonces     []string
+	bodies     []string
+	challenges int
+}
+
+func (s *digestServer) hash(data string) string {
+	var h hash.Hash
+	if strings.HasPrefix(s.algorithm, "SHA-256") {
+		h = sha256.New()
+	} else {
+		h = md5.New()
+	}
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *digestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authorization := r.Header.Get(reqx.HeaderAuthorization)
+	if !strings.HasPrefix(authorization, "Digest ") {
+		s.challenges++
+		w.Header().Set(reqx.HeaderWWWAuthenticate, `Basic realm="test"`)
+		w.Header().Add(reqx.HeaderWWWAuthenticate, fmt.Sprintf(`Digest realm="test@reqx", qop="auth,auth-int", algorithm=%s, nonce="%s", opaque="xyz"`, s.algorithm, s.nonce))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	params := map[string]string{}
+	for _, m := range digestParamRegexp.FindAllStringSubmatch(authorization, -1) {
+		params[m[1]] = m[2] + m[3]
+	}
+
+	ha1 := s.hash("user:test@reqx:pass")
+	if strings.HasSuffix(s.algorithm, "-sess") {
+		ha1 = s.hash(ha1 + ":" + params["nonce"] + ":" + params["cnonce"])
+	}
+	ha2 := s.hash(r.Method + ":" + params["uri"])
+	expected := s.hash(ha1 + ":" + params["nonce"] + ":" + params["nc"] + ":" + params["cnonce"] + ":" + params["qop"] + ":" + ha2)
+
+	if params["response"] != expected || params["opaque"] != "xyz" || params["algorithm"] != s.algorithm || params["uri"] != r.URL.RequestURI() {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if params["nc"] <= s.lastNc {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.lastNc = params["nc"]
+	s.nonces = append(s.nonces, params["nc"])
+	s.bodies = append(s.bodies, string(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+func Test_DigestAuth(t *testing.T) {
+	for _, algorithm := range []string{"MD5", "MD5-sess", "SHA-256", "SHA-256-sess"} {
+		t.Run(algorithm, func(t *testing.T) {
+			server := &digestServer{
+				algorithm: algorithm,
+				nonce:     "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+			}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			client := reqx.New(
+				reqx.WithTimeout(10*time.Second),
+				reqx.WithAuthenticator(reqx.DigestAuth("user", "pass")),
+			)
+
+			values := url.Values{"name": {"reqx"}}
+			requests := []*reqx.Request{
+				{URL: ts.URL + "/dir/index.html?a=1", Data: reqx.Raw{Body: []byte("raw")}},
+				{URL: ts.URL + "/dir/index.html", Data: &Data{Name: "Reqx"}},
+				{URL: ts.URL + "/upload", Data: &reqx.Form{
+					FormData: reqx.FormData{"firstName": "reqx"},
+					Files: reqx.WithFileParams(
+						reqx.WithFileParam("file1", "test1.txt", bytes.NewReader([]byte("file content"))),
+					),
+				}},
+				{URL: ts.URL + "/form", Data: &reqx.FormUrlEncoded{Values: &values}},
+			}
+
+			for _, request := range requests {
+				resp, err := client.Post(request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("Test_DigestAuth Error: status %d for %s", resp.StatusCode, request.URL)
+				}
+			}
+
+			if server.challenges != 1 {
+				t.Errorf("Test_DigestAuth Error: %d unauthenticated requests, want 1", server.challenges)
+			}
+			if strings.Join(server.nonces, ",") != "00000001,00000002,00000003,00000004" {
+				t.Errorf("Test_DigestAuth Error: nonce counts %v", server.nonces)
+			}
+			if server.bodies[0] != "raw" || server.bodies[1] != `{"name":"Reqx"}` ||
+				!strings.Contains(server.bodies[2], "file content") || server.bodies[3] != "name=reqx" {
+				t.Errorf("Test_DigestAuth Error: bodies %q", server.bodies)
+			}
+		})
+	}
+}
+
+func Test_DigestAuth_WrongPassword(t *testing.T) {
+	server := &digestServer{
+		algorithm: "MD5",
+		nonce:     "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.ServeHTTP(&unauthorizedOnForbidden{ResponseWriter: w}, r)
+	}))
+	defer ts.Close()
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(reqx.DigestAuth("user", "wrong")),
+	)
+
+	resp, err := client.Get(&reqx.Request{
+		URL: ts.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Test_DigestAuth_WrongPassword Error: status %d", resp.StatusCode)
+	}
+}
+
+type unauthorizedOnForbidden struct {
+	http.ResponseWriter
+}
+
+func (w *unauthorizedOnForbidden) WriteHeader(statusCode int) {
+	if statusCode == http.StatusForbidden {
+		statusCode = http.StatusUnauthorized
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func Test_DigestAuth_QuotedStrings(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get(reqx.HeaderAuthorization)
+		if authorization == "" {
+			w.Header().Set(reqx.HeaderWWWAuthenticate, `Digest realm="say \"hi\" \\ reqx", qop="auth", nonce="abc"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(reqx.DigestAuth(`us"er\`, "pass")),
+	)
+	_, err := client.Get(&reqx.Request{URL: ts.URL + `/a"b`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(authorization, `username="us\"er\\", realm="say \"hi\" \\ reqx"`) || !strings.Contains(authorization, `uri="/a\"b"`) {
+		t.Errorf("Test_DigestAuth_QuotedStrings Error: %s", authorization)
+	}
+}
@@ -2,9 +2,9 @@ package main
 
 import (
 	"bytes"
-	"fmt"
 	"github.com/dreamph/reqx"
 	"log"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -24,15 +24,10 @@ func main() {
 		reqx.WithHeaders(reqx.Headers{
 			reqx.HeaderAuthorization: "Bearer 123456",
 		}),
-		reqx.WithOnBeforeRequest(func(req *reqx.RequestInfo) {
-			fmt.Println(req.String())
-		}),
-		reqx.WithOnRequestCompleted(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
-			fmt.Println(resp.String())
-		}),
-		reqx.WithOnRequestError(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
-			fmt.Println(resp.String())
-		}),
+		reqx.WithLogger(slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			reqx.WithLogBodies(1024),
+			reqx.WithLogRedactJSONFields("password", "token"),
+		),
 	)
 
 	//POST
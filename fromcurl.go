@@ -0,0 +1,519 @@
+package reqx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCurl = errors.New("reqx: invalid curl command")
+	// ErrCurlFileNotAllowed is returned by FromCurl for a file referenced with @ or < without WithCurlReadFile.
+	ErrCurlFileNotAllowed = errors.New("reqx: reading files of curl commands is not allowed")
+)
+
+type FromCurlOption struct {
+	// ReadFile reads the files referenced with @ or <. Files are rejected when it is nil.
+	ReadFile func(name string) ([]byte, error)
+}
+
+type FromCurlOptions func(opts *FromCurlOption)
+
+// WithCurlReadFile lets FromCurl read the files referenced with @ or < using readFile, e.g. os.ReadFile.
+// Only use it for trusted commands, e.g. -d @~/.ssh/id_rsa would send the file.
+func WithCurlReadFile(readFile func(name string) ([]byte, error)) FromCurlOptions {
+	return func(opts *FromCurlOption) {
+		opts.ReadFile = readFile
+	}
+}
+
+// curlFlagsWithValue maps the supported curl flags that take a value to their long name.
+// Flags mapped to "" are accepted and ignored.
+var curlFlagsWithValue = map[string]string{
+	"-X":                "--request",
+	"--request":         "--request",
+	"-H":                "--header",
+	"--header":          "--header",
+	"-d":                "--data",
+	"--data":            "--data",
+	"--data-ascii":      "--data",
+	"--data-raw":        "--data-raw",
+	"--data-binary":     "--data-binary",
+	"--data-urlencode":  "--data-urlencode",
+	"--json":            "--json",
+	"-F":                "--form",
+	"--form":            "--form",
+	"--form-string":     "--form-string",
+	"-u":                "--user",
+	"--user":            "--user",
+	"-A":                "--user-agent",
+	"--user-agent":      "--user-agent",
+	"-e":                "--referer",
+	"--referer":         "--referer",
+	"-b":                "--cookie",
+	"--cookie":          "--cookie",
+	"-m":                "--max-time",
+	"--max-time":        "--max-time",
+	"--url":             "--url",
+	"-o":                "",
+	"--output":          "",
+	"-w":                "",
+	"--write-out":       "",
+	"--connect-timeout": "",
+	"--retry":           "",
+}
+
+// curlFlags maps the supported curl flags without a value to their long name.
+// Flags mapped to "" are accepted and ignored.
+var curlFlags = map[string]string{
+	"-G":           "--get",
+	"--get":        "--get",
+	"-I":           "--head",
+	"--head":       "--head",
+	"--compressed": "",
+	"-s":           "",
+	"--silent":     "",
+	"-S":           "",
+	"--show-error": "",
+	"-v":           "",
+	"--verbose":    "",
+	"-i":           "",
+	"--include":    "",
+	"-L":           "",
+	"--location":   "",
+	"-k":           "",
+	"--insecure":   "",
+	"-f":           "",
+	"--fail":       "",
+	"-g":           "",
+	"--globoff":    "",
+	"--http1.1":    "",
+	"--http2":      "",
+}
+
+// FromCurl parses a curl command into a Request and its method.
+// Supported flags are -X, -H, -d, --data-raw, --data-binary, --data-urlencode, --json, -F, --form-string,
+// -u, -G, -I, -A, -e, -b and -m. Output and connection flags such as -s, -L, -k and --compressed are ignored.
+// Files referenced with @ or < fail with ErrCurlFileNotAllowed unless WithCurlReadFile is set,
+// they are read when parsing.
+func FromCurl(cmd string, opts ...FromCurlOptions) (*Request, string, error) {
+	opt := &FromCurlOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	args, err := splitCurlArgs(cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(args) == 0 || args[0] != "curl" {
+		return nil, "", fmt.Errorf("%w: must start with curl", ErrInvalidCurl)
+	}
+
+	p := &curlParser{
+		request:  &Request{Headers: Headers{}},
+		readFile: opt.ReadFile,
+	}
+	err = p.parse(args[1:])
+	if err != nil {
+		return nil, "", err
+	}
+	return p.build()
+}
+
+type curlParser struct {
+	request  *Request
+	readFile func(name string) ([]byte, error)
+	method   string
+	rawURL   string
+	data     []string
+	form     *Form
+	get      bool
+	head     bool
+	hasData  bool
+	hasForm  bool
+	jsonData bool
+}
+
+func (p *curlParser) parse(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if p.rawURL != "" {
+				return fmt.Errorf("%w: unexpected argument %q", ErrInvalidCurl, arg)
+			}
+			p.rawURL = arg
+			continue
+		}
+
+		flag, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			// -XPOST or combined flags such as -sSL.
+			if _, ok := curlFlagsWithValue[arg[:2]]; ok {
+				flag, value, hasValue = arg[:2], arg[2:], true
+			} else {
+				for _, c := range arg[1:] {
+					err := p.flag("-" + string(c))
+					if err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+
+		if name, ok := curlFlagsWithValue[flag]; ok {
+			if !hasValue {
+				if i+1 >= len(args) {
+					return fmt.Errorf("%w: %s requires a value", ErrInvalidCurl, flag)
+				}
+				i++
+				value = args[i]
+			}
+			err := p.value(name, value)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err := p.flag(flag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *curlParser) flag(flag string) error {
+	name, ok := curlFlags[flag]
+	if !ok {
+		return fmt.Errorf("%w: unsupported flag %s", ErrInvalidCurl, flag)
+	}
+	switch name {
+	case "--get":
+		p.get = true
+	case "--head":
+		p.head = true
+	}
+	return nil
+}
+
+func (p *curlParser) value(name string, value string) error {
+	switch name {
+	case "--request":
+		p.method = strings.ToUpper(value)
+	case "--url":
+		p.rawURL = value
+	case "--header":
+		key, v, ok := strings.Cut(value, ":")
+		if !ok {
+			return fmt.Errorf("%w: invalid header %q", ErrInvalidCurl, value)
+		}
+		p.setHeader(strings.TrimSpace(key), strings.TrimSpace(v))
+	case "--data", "--data-binary":
+		data, err := p.readData(value, name == "--data")
+		if err != nil {
+			return err
+		}
+		p.addData(data)
+	case "--data-raw":
+		p.addData(value)
+	case "--data-urlencode":
+		data, err := p.encodeData(value)
+		if err != nil {
+			return err
+		}
+		p.addData(data)
+	case "--json":
+		data, err := p.readData(value, false)
+		if err != nil {
+			return err
+		}
+		p.addData(data)
+		p.jsonData = true
+	case "--form", "--form-string":
+		return p.addForm(value, name == "--form-string")
+	case "--user":
+		p.setHeader(HeaderAuthorization, "Basic "+base64.StdEncoding.EncodeToString([]byte(value)))
+	case "--user-agent":
+		p.setHeader("User-Agent", value)
+	case "--referer":
+		p.setHeader("Referer", value)
+	case "--cookie":
+		p.setHeader("Cookie", value)
+	case "--max-time":
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid max time %q", ErrInvalidCurl, value)
+		}
+		p.request.Timeout = time.Duration(seconds * float64(time.Second))
+	}
+	return nil
+}
+
+func (p *curlParser) setHeader(key string, value string) {
+	if strings.EqualFold(key, HeaderContentType) {
+		key = HeaderContentType
+	}
+	p.request.Headers[key] = value
+}
+
+func (p *curlParser) addData(data string) {
+	p.hasData = true
+	p.data = append(p.data, data)
+}
+
+func (p *curlParser) addForm(value string, literal bool) error {
+	p.hasForm = true
+	if p.form == nil {
+		p.form = &Form{FormData: FormData{}}
+	}
+
+	name, v, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("%w: invalid form field %q", ErrInvalidCurl, value)
+	}
+	if literal || (!strings.HasPrefix(v, "@") && !strings.HasPrefix(v, "<")) {
+		p.form.FormData[name] = v
+		return nil
+	}
+
+	// name=@path;type=text/plain;filename=name.txt or name=<path
+	attrs := strings.Split(v[1:], ";")
+	path := attrs[0]
+	content, err := p.read(path)
+	if err != nil {
+		return err
+	}
+	if v[0] == '<' {
+		p.form.FormData[name] = string(content)
+		return nil
+	}
+
+	fileName := path[strings.LastIndexAny(path, `/\`)+1:]
+	for _, attr := range attrs[1:] {
+		if after, found := strings.CutPrefix(attr, "filename="); found {
+			fileName = strings.Trim(after, `"`)
+		}
+	}
+	if p.form.Files == nil {
+		p.form.Files = WithFileParams()
+	}
+	*p.form.Files = append(*p.form.Files, WithFileParam(name, fileName, bytes.NewReader(content)))
+	return nil
+}
+
+func (p *curlParser) build() (*Request, string, error) {
+	if p.rawURL == "" {
+		return nil, "", fmt.Errorf("%w: missing URL", ErrInvalidCurl)
+	}
+	if p.hasData && p.hasForm {
+		return nil, "", fmt.Errorf("%w: data and form fields cannot be combined", ErrInvalidCurl)
+	}
+
+	requestURL := p.rawURL
+	if !strings.Contains(requestURL, "://") {
+		requestURL = "http://" + requestURL
+	}
+
+	method := http.MethodGet
+	switch {
+	case p.head:
+		method = http.MethodHead
+	case p.get:
+		if p.hasData {
+			separator := "?"
+			if strings.Contains(requestURL, "?") {
+				separator = "&"
+			}
+			requestURL += separator + strings.Join(p.data, "&")
+		}
+	case p.hasData:
+		method = http.MethodPost
+		if p.jsonData {
+			p.request.Data = Raw{Body: []byte(strings.Join(p.data, ""))}
+			p.setDefaultHeader(HeaderContentType, HeaderContentTypeJson)
+			p.setDefaultHeader("Accept", HeaderContentTypeJson)
+		} else {
+			p.request.Data = Raw{Body: []byte(strings.Join(p.data, "&"))}
+			p.setDefaultHeader(HeaderContentType, HeaderContentTypeFormUrlEncoded)
+		}
+	case p.hasForm:
+		method = http.MethodPost
+		p.request.Data = p.form
+	}
+	if p.method != "" {
+		method = p.method
+	}
+
+	_, err := url.Parse(requestURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidCurl, err)
+	}
+	p.request.URL = requestURL
+	if len(p.request.Headers) == 0 {
+		p.request.Headers = nil
+	}
+	return p.request, method, nil
+}
+
+func (p *curlParser) setDefaultHeader(key string, value string) {
+	for k := range p.request.Headers {
+		if strings.EqualFold(k, key) {
+			return
+		}
+	}
+	p.request.Headers[key] = value
+}
+
+// read reads the file name with readFile.
+func (p *curlParser) read(name string) ([]byte, error) {
+	if p.readFile == nil {
+		return nil, fmt.Errorf("%w: %s", ErrCurlFileNotAllowed, name)
+	}
+	return p.readFile(name)
+}
+
+// readData reads @file values. -d strips new lines from files like curl does.
+func (p *curlParser) readData(value string, stripNewLines bool) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	}
+	content, err := p.read(value[1:])
+	if err != nil {
+		return "", err
+	}
+	data := string(content)
+	if stripNewLines {
+		data = strings.NewReplacer("\r", "", "\n", "").Replace(data)
+	}
+	return data, nil
+}
+
+// encodeData implements the content, =content, name=content, @file and name@file forms of --data-urlencode.
+func (p *curlParser) encodeData(value string) (string, error) {
+	eq := strings.IndexByte(value, '=')
+	at := strings.IndexByte(value, '@')
+
+	switch {
+	case eq == 0:
+		return url.QueryEscape(value[1:]), nil
+	case eq > 0 && (at < 0 || eq < at):
+		return value[:eq] + "=" + url.QueryEscape(value[eq+1:]), nil
+	case at >= 0:
+		content, err := p.read(value[at+1:])
+		if err != nil {
+			return "", err
+		}
+		if at == 0 {
+			return url.QueryEscape(string(content)), nil
+		}
+		return value[:at] + "=" + url.QueryEscape(string(content)), nil
+	}
+	return url.QueryEscape(value), nil
+}
+
+// splitCurlArgs splits a shell command line. It understands single quotes, double quotes, $'...' strings,
+// backslash escapes and line continuations.
+func splitCurlArgs(cmd string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+
+	for i := 0; i < len(cmd); i++ {
+		c := cmd[i]
+		switch {
+		case c == '\\' && i+1 < len(cmd) && (cmd[i+1] == '\n' || cmd[i+1] == '\r'):
+			i++
+			if cmd[i] == '\r' && i+1 < len(cmd) && cmd[i+1] == '\n' {
+				i++
+			}
+		case c == '\\' && i+1 < len(cmd):
+			i++
+			current.WriteByte(cmd[i])
+			inArg = true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case c == '\'':
+			end := strings.IndexByte(cmd[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+			}
+			current.WriteString(cmd[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		case c == '$' && i+1 < len(cmd) && cmd[i+1] == '\'':
+			n, err := readANSIQuoted(cmd[i+2:], &current)
+			if err != nil {
+				return nil, err
+			}
+			i += n + 1
+			inArg = true
+		case c == '"':
+			n, err := readDoubleQuoted(cmd[i+1:], &current)
+			if err != nil {
+				return nil, err
+			}
+			i += n
+			inArg = true
+		default:
+			current.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// readDoubleQuoted reads up to the closing quote and returns the number of bytes consumed including it.
+func readDoubleQuoted(s string, sb *strings.Builder) (int, error) {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`\n", s[i+1]) >= 0:
+			i++
+			if s[i] != '\n' {
+				sb.WriteByte(s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+}
+
+// readANSIQuoted reads a $'...' string up to the closing quote and returns the number of bytes consumed including it.
+func readANSIQuoted(s string, sb *strings.Builder) (int, error) {
+	escapes := map[byte]byte{'n': '\n', 't': '\t', 'r': '\r', '\\': '\\', '\'': '\'', '"': '"'}
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\'':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s):
+			i++
+			if e, ok := escapes[s[i]]; ok {
+				sb.WriteByte(e)
+			} else {
+				sb.WriteByte('\\')
+				sb.WriteByte(s[i])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return 0, fmt.Errorf("%w: unterminated quote", ErrInvalidCurl)
+}
@@ -0,0 +1,165 @@
+package reqx_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dreamph/reqx"
+)
+
+func Test_FromCurl(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "avatar.png")
+	err := os.WriteFile(filePath, []byte("png"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cmd     string
+		method  string
+		url     string
+		headers reqx.Headers
+		body    string
+	}{
+		{
+			name:   "get",
+			cmd:    `curl https://api.example.com/users`,
+			method: http.MethodGet,
+			url:    "https://api.example.com/users",
+		},
+		{
+			name: "json with continuation",
+			cmd: `curl -X PUT "https://api.example.com/users/1?x=\"y\"" \
+  -H 'Content-Type: application/json' \
+  -H "Authorization: Bearer abc" \
+  --data-raw '{"name":"it'\''s"}'`,
+			method:  http.MethodPut,
+			url:     `https://api.example.com/users/1?x="y"`,
+			headers: reqx.Headers{reqx.HeaderContentType: reqx.HeaderContentTypeJson, reqx.HeaderAuthorization: "Bearer abc"},
+			body:    `{"name":"it's"}`,
+		},
+		{
+			name:    "data defaults to post form",
+			cmd:     `curl -sSL api.example.com/login -d user=a -d 'pass=b' -u admin:secret`,
+			method:  http.MethodPost,
+			url:     "http://api.example.com/login",
+			headers: reqx.Headers{reqx.HeaderContentType: reqx.HeaderContentTypeFormUrlEncoded, reqx.HeaderAuthorization: "Basic YWRtaW46c2VjcmV0"},
+			body:    "user=a&pass=b",
+		},
+		{
+			name:    "data urlencode",
+			cmd:     `curl -XPOST https://api.example.com/search --data-urlencode 'q=a b&c' --data-urlencode '=x y' --compressed`,
+			method:  http.MethodPost,
+			url:     "https://api.example.com/search",
+			headers: reqx.Headers{reqx.HeaderContentType: reqx.HeaderContentTypeFormUrlEncoded},
+			body:    "q=a+b%26c&x+y",
+		},
+		{
+			name:   "get with data",
+			cmd:    `curl -G https://api.example.com/search?page=1 --data-urlencode "q=reqx go" -m 2.5`,
+			method: http.MethodGet,
+			url:    "https://api.example.com/search?page=1&q=reqx+go",
+		},
+		{
+			name:    "json",
+			cmd:     `curl --json $'{"a":\n1}' https://api.example.com`,
+			method:  http.MethodPost,
+			url:     "https://api.example.com",
+			headers: reqx.Headers{reqx.HeaderContentType: reqx.HeaderContentTypeJson, "Accept": reqx.HeaderContentTypeJson},
+			body:    "{\"a\":\n1}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, method, err := reqx.FromCurl(tt.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || request.URL != tt.url {
+				t.Errorf("FromCurl() = %s %s, want %s %s", method, request.URL, tt.method, tt.url)
+			}
+			if len(request.Headers) != len(tt.headers) {
+				t.Errorf("FromCurl() headers = %v, want %v", request.Headers, tt.headers)
+			}
+			for k, v := range tt.headers {
+				if request.Headers[k] != v {
+					t.Errorf("FromCurl() header %s = %q, want %q", k, request.Headers[k], v)
+				}
+			}
+			var body string
+			if raw, ok := request.Data.(reqx.Raw); ok {
+				body = string(raw.Body)
+			}
+			if body != tt.body {
+				t.Errorf("FromCurl() body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+
+	request, _, err := reqx.FromCurl(`curl -m 2.5 https://api.example.com`)
+	if err != nil || request.Timeout != 2500*time.Millisecond {
+		t.Errorf("FromCurl() timeout = %v, %v", request.Timeout, err)
+	}
+
+	for _, cmd := range []string{
+		`curl https://api.example.com -d @` + filePath,
+		`curl https://api.example.com --data-urlencode name@` + filePath,
+		`curl https://api.example.com -F "avatar=@` + filePath + `"`,
+		`curl https://api.example.com -F "avatar=<` + filePath + `"`,
+	} {
+		_, _, err = reqx.FromCurl(cmd)
+		if !errors.Is(err, reqx.ErrCurlFileNotAllowed) {
+			t.Errorf("FromCurl(%s) error = %v", cmd, err)
+		}
+	}
+
+	request, method, err := reqx.FromCurl(`curl https://api.example.com/upload -F name=reqx -F "avatar=@`+filePath+`;type=image/png" --form-string 'note=@home'`, reqx.WithCurlReadFile(os.ReadFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	form, ok := request.Data.(*reqx.Form)
+	if method != http.MethodPost || !ok || form.FormData["name"] != "reqx" || form.FormData["note"] != "@home" || len(*form.Files) != 1 {
+		t.Fatalf("FromCurl() form = %s %+v", method, request.Data)
+	}
+	file := (*form.Files)[0]
+	content, _ := io.ReadAll(file.Reader)
+	if file.Name != "avatar" || file.FileName != "avatar.png" || string(content) != "png" {
+		t.Errorf("FromCurl() file = %+v %s", file, content)
+	}
+
+	for _, cmd := range []string{`wget https://example.com`, `curl -Z https://example.com`, `curl 'https://example.com`, `curl -H`} {
+		_, _, err = reqx.FromCurl(cmd)
+		if !errors.Is(err, reqx.ErrInvalidCurl) {
+			t.Errorf("FromCurl(%s) error = %v", cmd, err)
+		}
+	}
+}
+
+func Test_FromCurl_RoundTrip(t *testing.T) {
+	client := reqx.New(reqx.WithHeaders(reqx.Headers{"X-Api-Key": "key"}))
+	cmd, err := reqx.Curl(client, http.MethodPatch, &reqx.Request{
+		URL:  "https://api.example.com/users/1",
+		Data: &Data{Name: "it's reqx"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, method, err := reqx.FromCurl(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := request.Data.(reqx.Raw)
+	if method != http.MethodPatch || request.URL != "https://api.example.com/users/1" || request.Headers["X-Api-Key"] != "key" ||
+		request.Headers[reqx.HeaderContentType] != reqx.HeaderContentTypeJson || string(raw.Body) != `{"name":"it's reqx"}` {
+		t.Errorf("FromCurl() = %s %+v %s", method, request, raw.Body)
+	}
+}
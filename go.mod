@@ -1,14 +1,21 @@
 module github.com/dreamph/reqx
 
-go 1.22
+go 1.22.0
 
 require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/fasthttp/websocket v1.5.12
 	github.com/goccy/go-json v0.10.4
+	github.com/klauspost/compress v1.18.0
 	github.com/valyala/fasthttp v1.58.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/andybalholm/brotli v1.1.1 // indirect
-	github.com/klauspost/compress v1.17.11 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/rogpeppe/go-internal v1.13.1 // indirect
+	github.com/savsgio/gotils v0.0.0-20240704082632-aef3928b8a38 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
@@ -0,0 +1,102 @@
+package har_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/har"
+)
+
+func Test_Recorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/", HttpOnly: true})
+		w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte(`{"compressed":true}`))
+			_ = gz.Close()
+			return
+		}
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	recorder := har.NewRecorder()
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithOnRequestCompleted(recorder.OnRequestCompleted),
+	)
+
+	values := url.Values{"name": {"reqx"}}
+	requests := []*reqx.Request{
+		{URL: ts.URL + "/form?page=1", Data: &reqx.FormUrlEncoded{Values: &values}},
+		{URL: ts.URL + "/upload", Data: &reqx.Form{
+			FormData: reqx.FormData{"firstName": "reqx"},
+			Files: reqx.WithFileParams(
+				reqx.WithFileParam("file1", "test1.txt", bytes.NewReader([]byte("file content"))),
+			),
+		}},
+		{URL: ts.URL + "/gzip", Headers: reqx.Headers{"Cookie": "id=1"}},
+	}
+	for _, request := range requests {
+		_, err := client.Post(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "session.har")
+	err := recorder.WriteFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &har.HAR{}
+	err = json.Unmarshal(data, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Log.Version != har.Version || len(doc.Log.Entries) != 3 {
+		t.Fatalf("Test_Recorder Error: %s", data)
+	}
+
+	form := doc.Log.Entries[0]
+	if form.Request.Method != http.MethodPost || form.Request.QueryString[0].Name != "page" ||
+		form.Request.PostData.Params[0].Name != "name" || form.Request.PostData.Params[0].Value != "reqx" {
+		t.Errorf("Test_Recorder Error: form request %+v", form.Request)
+	}
+	if form.Response.Status != http.StatusOK || form.Response.Content.Text != `{"ok":true}` ||
+		form.Response.Cookies[0].Name != "session" || !form.Response.Cookies[0].HTTPOnly {
+		t.Errorf("Test_Recorder Error: form response %+v", form.Response)
+	}
+
+	params := map[string]*har.Param{}
+	for _, param := range doc.Log.Entries[1].Request.PostData.Params {
+		params[param.Name] = param
+	}
+	if params["firstName"].Value != "reqx" || params["file1"].FileName != "test1.txt" || params["file1"].Value != "file content" {
+		t.Errorf("Test_Recorder Error: multipart params %+v %+v", params["firstName"], params["file1"])
+	}
+
+	compressed := doc.Log.Entries[2]
+	if compressed.Response.Content.Text != `{"compressed":true}` || compressed.Response.Content.Size != 19 {
+		t.Errorf("Test_Recorder Error: content %+v", compressed.Response.Content)
+	}
+	if compressed.Request.Cookies[0].Name != "id" || compressed.Timings.Wait != compressed.Time {
+		t.Errorf("Test_Recorder Error: %+v %+v", compressed.Request.Cookies, compressed.Timings)
+	}
+}
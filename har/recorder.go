@@ -0,0 +1,278 @@
+package har
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+const (
+	creatorName = "reqx"
+	modulePath  = "github.com/dreamph/reqx"
+)
+
+// Recorder collects HAR entries of completed requests.
+// Use it with reqx.WithOnRequestCompleted(recorder.OnRequestCompleted).
+type Recorder struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) OnRequestCompleted(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+	entry := NewEntry(req, resp)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, entry)
+}
+
+func (r *Recorder) Entries() []*Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Entry(nil), r.entries...)
+}
+
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+func (r *Recorder) HAR() *HAR {
+	return &HAR{
+		Log: &Log{
+			Version: Version,
+			Creator: &Creator{
+				Name:    creatorName,
+				Version: moduleVersion(),
+			},
+			Entries: r.Entries(),
+		},
+	}
+}
+
+// Write writes the recorded entries as a HAR document.
+func (r *Recorder) Write(w io.Writer) error {
+	encoder := gojson.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r.HAR())
+}
+
+func (r *Recorder) WriteFile(path string) error {
+	var buf bytes.Buffer
+	err := r.Write(&buf)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// NewEntry builds a HAR entry from a completed request. Only the total time is known,
+// so it is reported as the wait timing.
+func NewEntry(req *reqx.RequestInfo, resp *reqx.ResponseInfo) *Entry {
+	totalTime := float64(resp.TotalTime) / float64(time.Millisecond)
+	entry := &Entry{
+		StartedDateTime: time.Now().Add(-resp.TotalTime),
+		Time:            totalTime,
+		Request:         newRequest(req.Request),
+		Response:        newResponse(resp.Response),
+		Cache:           &Cache{},
+		Timings: &Timings{
+			Blocked: -1,
+			DNS:     -1,
+			Connect: -1,
+			Send:    0,
+			Wait:    totalTime,
+			Receive: 0,
+			SSL:     -1,
+		},
+	}
+	if resp.Err != nil {
+		entry.Response.Status = 0
+		entry.Response.StatusText = ""
+		entry.Comment = resp.Err.Error()
+	}
+	return entry
+}
+
+func newRequest(req *fasthttp.Request) *Request {
+	r := &Request{
+		Method:      string(req.Header.Method()),
+		URL:         req.URI().String(),
+		HTTPVersion: string(req.Header.Protocol()),
+		Cookies:     []*Cookie{},
+		Headers:     []*NameValue{},
+		QueryString: []*NameValue{},
+		HeadersSize: len(req.Header.Header()),
+		BodySize:    len(req.Body()),
+	}
+
+	req.Header.VisitAll(func(key, value []byte) {
+		r.Headers = append(r.Headers, &NameValue{Name: string(key), Value: string(value)})
+	})
+	req.Header.VisitAllCookie(func(key, value []byte) {
+		r.Cookies = append(r.Cookies, &Cookie{Name: string(key), Value: string(value)})
+	})
+	req.URI().QueryArgs().VisitAll(func(key, value []byte) {
+		r.QueryString = append(r.QueryString, &NameValue{Name: string(key), Value: string(value)})
+	})
+
+	if body := req.Body(); len(body) > 0 {
+		r.PostData = newPostData(string(req.Header.ContentType()), body)
+	}
+	return r
+}
+
+func newPostData(contentType string, body []byte) *PostData {
+	postData := &PostData{
+		MimeType: contentType,
+		Params:   []*Param{},
+	}
+	if utf8.Valid(body) {
+		postData.Text = string(body)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return postData
+	}
+
+	switch {
+	case mediaType == reqx.HeaderContentTypeFormUrlEncoded:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return postData
+		}
+		for name, vv := range values {
+			for _, v := range vv {
+				postData.Params = append(postData.Params, &Param{Name: name, Value: v})
+			}
+		}
+	case strings.HasPrefix(mediaType, "multipart/"):
+		postData.Params = multipartParams(body, params["boundary"])
+	}
+	return postData
+}
+
+func multipartParams(body []byte, boundary string) []*Param {
+	result := []*Param{}
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return result
+			}
+			break
+		}
+
+		param := &Param{
+			Name:        part.FormName(),
+			FileName:    part.FileName(),
+			ContentType: part.Header.Get(reqx.HeaderContentType),
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return result
+		}
+		if param.FileName == "" || utf8.Valid(data) {
+			param.Value = string(data)
+		}
+		result = append(result, param)
+	}
+	return result
+}
+
+func newResponse(resp *fasthttp.Response) *Response {
+	r := &Response{
+		Status:      resp.StatusCode(),
+		StatusText:  string(resp.Header.StatusMessage()),
+		HTTPVersion: string(resp.Header.Protocol()),
+		Cookies:     []*Cookie{},
+		Headers:     []*NameValue{},
+		RedirectURL: string(resp.Header.Peek(fasthttp.HeaderLocation)),
+		HeadersSize: len(resp.Header.Header()),
+		BodySize:    len(resp.Body()),
+	}
+	if r.StatusText == "" {
+		r.StatusText = http.StatusText(r.Status)
+	}
+
+	resp.Header.VisitAll(func(key, value []byte) {
+		r.Headers = append(r.Headers, &NameValue{Name: string(key), Value: string(value)})
+	})
+	resp.Header.VisitAllCookie(func(key, value []byte) {
+		r.Cookies = append(r.Cookies, newCookie(value))
+	})
+
+	body, err := resp.BodyUncompressed()
+	if err != nil {
+		body = resp.Body()
+	}
+	r.Content = &Content{
+		Size:        len(body),
+		Compression: len(body) - len(resp.Body()),
+		MimeType:    string(resp.Header.ContentType()),
+	}
+	if utf8.Valid(body) {
+		r.Content.Text = string(body)
+	} else {
+		r.Content.Text = base64.StdEncoding.EncodeToString(body)
+		r.Content.Encoding = "base64"
+	}
+	return r
+}
+
+func newCookie(setCookie []byte) *Cookie {
+	c := fasthttp.AcquireCookie()
+	defer fasthttp.ReleaseCookie(c)
+
+	if c.ParseBytes(setCookie) != nil {
+		return &Cookie{Value: string(setCookie)}
+	}
+
+	cookie := &Cookie{
+		Name:     string(c.Key()),
+		Value:    string(c.Value()),
+		Path:     string(c.Path()),
+		Domain:   string(c.Domain()),
+		HTTPOnly: c.HTTPOnly(),
+		Secure:   c.Secure(),
+	}
+	if expire := c.Expire(); !expire.Equal(fasthttp.CookieExpireUnlimited) {
+		cookie.Expires = &expire
+	}
+	return cookie
+}
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
@@ -0,0 +1,497 @@
+package httpsig
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+const (
+	AlgorithmHMACSHA256      = "hmac-sha256"
+	AlgorithmEd25519         = "ed25519"
+	AlgorithmECDSAP256SHA256 = "ecdsa-p256-sha256"
+	AlgorithmECDSAP384SHA384 = "ecdsa-p384-sha384"
+
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+
+	HeaderSignature      = "Signature"
+	HeaderSignatureInput = "Signature-Input"
+	HeaderContentDigest  = "Content-Digest"
+
+	ComponentMethod        = "@method"
+	ComponentTargetURI     = "@target-uri"
+	ComponentAuthority     = "@authority"
+	ComponentScheme        = "@scheme"
+	ComponentRequestTarget = "@request-target"
+	ComponentPath          = "@path"
+	ComponentQuery         = "@query"
+	ComponentStatus        = "@status"
+	ComponentContentDigest = "content-digest"
+
+	defaultLabel = "sig1"
+)
+
+var (
+	DefaultRequestComponents  = []string{ComponentMethod, ComponentTargetURI, ComponentContentDigest}
+	DefaultResponseComponents = []string{ComponentStatus, ComponentContentDigest}
+
+	ErrUnsupportedKey       = errors.New("httpsig: unsupported key type")
+	ErrUnsupportedAlgorithm = errors.New("httpsig: unsupported algorithm")
+	// ErrInvalidString is returned for a component name or parameter outside printable ASCII.
+	ErrInvalidString = errors.New("httpsig: string is not a valid structured field string")
+)
+
+type Options struct {
+	KeyID string
+	// Key is a []byte for hmac-sha256, an ed25519.PrivateKey or an *ecdsa.PrivateKey on P-256 or P-384.
+	Key any
+	// Label names the signature in the Signature and Signature-Input dictionaries. Defaults to sig1.
+	Label string
+	// Components lists the covered components. Header names must be lower case.
+	// Defaults to DefaultRequestComponents or DefaultResponseComponents.
+	Components []string
+	// DigestAlgorithm is used for the Content-Digest header. Defaults to sha-256.
+	DigestAlgorithm string
+	// Expires sets the expires parameter relative to the created time when positive.
+	Expires time.Duration
+	// Nonce returns a value for the nonce parameter when set.
+	Nonce func() string
+	Tag   string
+	// OmitAlgorithm leaves out the optional alg parameter.
+	OmitAlgorithm bool
+	Now           func() time.Time
+}
+
+type Signer struct {
+	opts      Options
+	algorithm string
+}
+
+func NewSigner(opts Options) (*Signer, error) {
+	algorithm, err := signingAlgorithm(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Label == "" {
+		opts.Label = defaultLabel
+	}
+	if opts.DigestAlgorithm == "" {
+		opts.DigestAlgorithm = DigestSHA256
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Signer{
+		opts:      opts,
+		algorithm: algorithm,
+	}, nil
+}
+
+func (s *Signer) Sign(req *reqx.RequestInfo) error {
+	return s.SignRequest(req.Request)
+}
+
+func (s *Signer) SignRequest(req *fasthttp.Request) error {
+	return s.sign(&requestMessage{req: req}, s.components(DefaultRequestComponents))
+}
+
+func (s *Signer) SignResponse(resp *fasthttp.Response) error {
+	return s.sign(&responseMessage{resp: resp}, s.components(DefaultResponseComponents))
+}
+
+func (s *Signer) components(defaults []string) []string {
+	if len(s.opts.Components) > 0 {
+		return s.opts.Components
+	}
+	return defaults
+}
+
+func (s *Signer) sign(msg message, components []string) error {
+	for _, component := range components {
+		if component == ComponentContentDigest {
+			digest, err := ContentDigest(s.opts.DigestAlgorithm, msg.body())
+			if err != nil {
+				return err
+			}
+			msg.setHeader(HeaderContentDigest, digest)
+			break
+		}
+	}
+
+	created := s.opts.Now().Unix()
+	params := signatureParams{
+		components: components,
+		created:    created,
+		keyID:      s.opts.KeyID,
+		tag:        s.opts.Tag,
+	}
+	if !s.opts.OmitAlgorithm {
+		params.algorithm = s.algorithm
+	}
+	if s.opts.Expires > 0 {
+		params.expires = created + int64(s.opts.Expires/time.Second)
+	}
+	if s.opts.Nonce != nil {
+		params.nonce = s.opts.Nonce()
+	}
+
+	serializedParams, err := params.serialize()
+	if err != nil {
+		return err
+	}
+	base, err := signatureBase(msg, components, serializedParams)
+	if err != nil {
+		return err
+	}
+
+	signature, err := signBase(s.opts.Key, base)
+	if err != nil {
+		return err
+	}
+
+	msg.setHeader(HeaderSignatureInput, s.opts.Label+"="+serializedParams)
+	msg.setHeader(HeaderSignature, s.opts.Label+"=:"+base64.StdEncoding.EncodeToString(signature)+":")
+	return nil
+}
+
+type signatureParams struct {
+	components []string
+	created    int64
+	expires    int64
+	nonce      string
+	keyID      string
+	algorithm  string
+	tag        string
+}
+
+func (p signatureParams) serialize() (string, error) {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, component := range p.components {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		quoted, err := quoteString(component)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(quoted)
+	}
+	sb.WriteByte(')')
+	if p.created > 0 {
+		sb.WriteString(";created=" + strconv.FormatInt(p.created, 10))
+	}
+	if p.expires > 0 {
+		sb.WriteString(";expires=" + strconv.FormatInt(p.expires, 10))
+	}
+	for _, param := range []struct{ key, value string }{
+		{"nonce", p.nonce},
+		{"keyid", p.keyID},
+		{"alg", p.algorithm},
+		{"tag", p.tag},
+	} {
+		if param.value == "" {
+			continue
+		}
+		quoted, err := quoteString(param.value)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(";" + param.key + "=" + quoted)
+	}
+	return sb.String(), nil
+}
+
+// quoteString serializes s as an RFC 8941 section 4.1.6 sf-string, which allows printable ASCII only.
+func quoteString(s string) (string, error) {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c > 0x7e {
+			return "", fmt.Errorf("%w: %q", ErrInvalidString, s)
+		}
+		if c == '"' || c == '\\' {
+			sb.WriteByte('\\')
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte('"')
+	return sb.String(), nil
+}
+
+// parseString parses the RFC 8941 section 4.2.5 sf-string at the start of s and returns the rest of s.
+func parseString(s string) (string, string, error) {
+	if s == "" || s[0] != '"' {
+		return "", "", ErrMalformedSignature
+	}
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			i++
+			if i == len(s) || (s[i] != '"' && s[i] != '\\') {
+				return "", "", ErrMalformedSignature
+			}
+			sb.WriteByte(s[i])
+		case c == '"':
+			return sb.String(), s[i+1:], nil
+		case c < 0x20 || c > 0x7e:
+			return "", "", ErrMalformedSignature
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", "", ErrMalformedSignature
+}
+
+// signatureBase builds the RFC 9421 section 2.5 signature base.
+func signatureBase(msg message, components []string, serializedParams string) ([]byte, error) {
+	var sb strings.Builder
+	for _, component := range components {
+		value, err := componentValue(msg, component)
+		if err != nil {
+			return nil, err
+		}
+		name, err := quoteString(component)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(name)
+		sb.WriteString(": ")
+		sb.WriteString(value)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(`"@signature-params": `)
+	sb.WriteString(serializedParams)
+	return []byte(sb.String()), nil
+}
+
+func componentValue(msg message, component string) (string, error) {
+	if strings.HasPrefix(component, "@") {
+		return msg.derived(component)
+	}
+
+	values := msg.header(component)
+	if len(values) == 0 {
+		return "", fmt.Errorf("httpsig: missing header %q", component)
+	}
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+	return strings.Join(values, ", "), nil
+}
+
+// ContentDigest returns an RFC 9530 Content-Digest header value for body.
+func ContentDigest(algorithm string, body []byte) (string, error) {
+	var sum []byte
+	switch algorithm {
+	case DigestSHA256:
+		h := sha256.Sum256(body)
+		sum = h[:]
+	case DigestSHA512:
+		h := sha512.Sum512(body)
+		sum = h[:]
+	default:
+		return "", fmt.Errorf("httpsig: unsupported digest algorithm %q", algorithm)
+	}
+	return algorithm + "=:" + base64.StdEncoding.EncodeToString(sum) + ":", nil
+}
+
+func signingAlgorithm(key any) (string, error) {
+	switch k := key.(type) {
+	case []byte:
+		return AlgorithmHMACSHA256, nil
+	case ed25519.PrivateKey:
+		return AlgorithmEd25519, nil
+	case *ecdsa.PrivateKey:
+		return ecdsaAlgorithm(&k.PublicKey)
+	}
+	return "", ErrUnsupportedKey
+}
+
+func ecdsaAlgorithm(key *ecdsa.PublicKey) (string, error) {
+	switch key.Curve.Params().BitSize {
+	case 256:
+		return AlgorithmECDSAP256SHA256, nil
+	case 384:
+		return AlgorithmECDSAP384SHA384, nil
+	}
+	return "", ErrUnsupportedKey
+}
+
+func signBase(key any, base []byte) ([]byte, error) {
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write(base)
+		return mac.Sum(nil), nil
+	case ed25519.PrivateKey:
+		return ed25519.Sign(k, base), nil
+	case *ecdsa.PrivateKey:
+		digest, size := ecdsaDigest(k.Curve.Params().BitSize, base)
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			return nil, err
+		}
+		// RFC 9421 uses the fixed size r || s encoding instead of ASN.1.
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func verifyBase(key any, algorithm string, base []byte, signature []byte) (bool, error) {
+	switch k := key.(type) {
+	case []byte:
+		if algorithm != "" && algorithm != AlgorithmHMACSHA256 {
+			return false, ErrUnsupportedAlgorithm
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write(base)
+		return hmac.Equal(mac.Sum(nil), signature), nil
+	case ed25519.PublicKey:
+		if algorithm != "" && algorithm != AlgorithmEd25519 {
+			return false, ErrUnsupportedAlgorithm
+		}
+		return ed25519.Verify(k, base, signature), nil
+	case *ecdsa.PublicKey:
+		expected, err := ecdsaAlgorithm(k)
+		if err != nil {
+			return false, err
+		}
+		if algorithm != "" && algorithm != expected {
+			return false, ErrUnsupportedAlgorithm
+		}
+		digest, size := ecdsaDigest(k.Curve.Params().BitSize, base)
+		if len(signature) != 2*size {
+			return false, nil
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest, r, s), nil
+	}
+	return false, ErrUnsupportedKey
+}
+
+func ecdsaDigest(bitSize int, base []byte) ([]byte, int) {
+	if bitSize == 384 {
+		h := crypto.SHA384.New()
+		h.Write(base)
+		return h.Sum(nil), 48
+	}
+	h := sha256.Sum256(base)
+	return h[:], 32
+}
+
+type message interface {
+	derived(component string) (string, error)
+	header(name string) []string
+	setHeader(name string, value string)
+	body() []byte
+}
+
+type requestMessage struct {
+	req *fasthttp.Request
+}
+
+func (m *requestMessage) derived(component string) (string, error) {
+	uri := m.req.URI()
+	path := string(uri.PathOriginal())
+	if path == "" {
+		path = "/"
+	}
+	query := string(uri.QueryString())
+
+	switch component {
+	case ComponentMethod:
+		return string(m.req.Header.Method()), nil
+	case ComponentTargetURI:
+		target := strings.ToLower(string(uri.Scheme())) + "://" + strings.ToLower(string(m.req.Host())) + path
+		if query != "" {
+			target += "?" + query
+		}
+		return target, nil
+	case ComponentAuthority:
+		return strings.ToLower(string(m.req.Host())), nil
+	case ComponentScheme:
+		return strings.ToLower(string(uri.Scheme())), nil
+	case ComponentRequestTarget:
+		if query != "" {
+			return path + "?" + query, nil
+		}
+		return path, nil
+	case ComponentPath:
+		return path, nil
+	case ComponentQuery:
+		return "?" + query, nil
+	}
+	return "", fmt.Errorf("httpsig: unsupported request component %q", component)
+}
+
+func (m *requestMessage) header(name string) []string {
+	var values []string
+	m.req.Header.VisitAll(func(key, value []byte) {
+		if strings.EqualFold(string(key), name) {
+			values = append(values, string(value))
+		}
+	})
+	return values
+}
+
+func (m *requestMessage) setHeader(name string, value string) {
+	m.req.Header.Set(name, value)
+}
+
+func (m *requestMessage) body() []byte {
+	return m.req.Body()
+}
+
+type responseMessage struct {
+	resp *fasthttp.Response
+}
+
+func (m *responseMessage) derived(component string) (string, error) {
+	if component == ComponentStatus {
+		return strconv.Itoa(m.resp.StatusCode()), nil
+	}
+	return "", fmt.Errorf("httpsig: unsupported response component %q", component)
+}
+
+func (m *responseMessage) header(name string) []string {
+	var values []string
+	m.resp.Header.VisitAll(func(key, value []byte) {
+		if strings.EqualFold(string(key), name) {
+			values = append(values, string(value))
+		}
+	})
+	return values
+}
+
+func (m *responseMessage) setHeader(name string, value string) {
+	m.resp.Header.Set(name, value)
+}
+
+func (m *responseMessage) body() []byte {
+	return m.resp.Body()
+}
@@ -0,0 +1,308 @@
+package httpsig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/httpsig"
+)
+
+func newRequest(body string) *fasthttp.Request {
+	req := fasthttp.AcquireRequest()
+	req.Header.DisableNormalizing()
+	req.Header.SetMethod(fasthttp.MethodPost)
+	req.SetRequestURI("https://example.com/foo?param=Value&Pet=dog")
+	req.Header.Set("Date", "Tue, 20 Apr 2021 02:07:55 GMT")
+	req.Header.SetContentType("application/json")
+	req.SetBodyString(body)
+	return req
+}
+
+// B.2.5 of RFC 9421.
+func Test_SignRequest_RFC9421_HMAC(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString("uzvJfB4u3N0Jy4T7NZ75MDVcr8zSTInedJtkgcu46YW4XByzNJjxBdtjUkdJPBtbmHhIDi6pcl8jsasjlTMtDQ==")
+	signer, err := httpsig.NewSigner(httpsig.Options{
+		KeyID:         "test-shared-secret",
+		Key:           key,
+		Label:         "sig-b25",
+		Components:    []string{"date", "@authority", "content-type"},
+		OmitAlgorithm: true,
+		Now: func() time.Time {
+			return time.Unix(1618884473, 0)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newRequest(`{"hello": "world"}`)
+	defer fasthttp.ReleaseRequest(req)
+
+	err = signer.SignRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInput := `sig-b25=("date" "@authority" "content-type");created=1618884473;keyid="test-shared-secret"`
+	if got := string(req.Header.Peek(httpsig.HeaderSignatureInput)); got != wantInput {
+		t.Errorf("Signature-Input = %s, want %s", got, wantInput)
+	}
+	wantSignature := "sig-b25=:pxcQw6G3AjtMBQjwo8XzkZf/bws5LelbaMk5rGIGtE8=:"
+	if got := string(req.Header.Peek(httpsig.HeaderSignature)); got != wantSignature {
+		t.Errorf("Signature = %s, want %s", got, wantSignature)
+	}
+}
+
+func Test_SignAndVerify(t *testing.T) {
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	secret := []byte("secret")
+
+	tests := []struct {
+		name       string
+		privateKey any
+		publicKey  any
+	}{
+		{name: httpsig.AlgorithmHMACSHA256, privateKey: secret, publicKey: secret},
+		{name: httpsig.AlgorithmEd25519, privateKey: edPrivate, publicKey: edPublic},
+		{name: httpsig.AlgorithmECDSAP256SHA256, privateKey: p256, publicKey: &p256.PublicKey},
+		{name: httpsig.AlgorithmECDSAP384SHA384, privateKey: p384, publicKey: &p384.PublicKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := httpsig.NewSigner(httpsig.Options{
+				KeyID: "key-1",
+				Key:   tt.privateKey,
+				Components: []string{
+					httpsig.ComponentMethod,
+					httpsig.ComponentTargetURI,
+					httpsig.ComponentContentDigest,
+					"content-type",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier := httpsig.NewVerifier(httpsig.VerifyOptions{
+				Key: func(keyID string) (any, error) {
+					if keyID != "key-1" {
+						return nil, errors.New("unknown key")
+					}
+					return tt.publicKey, nil
+				},
+				RequiredComponents: []string{httpsig.ComponentContentDigest},
+				MaxAge:             time.Minute,
+			})
+
+			req := newRequest(`{"amount":100}`)
+			defer fasthttp.ReleaseRequest(req)
+
+			err = signer.SignRequest(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(req.Header.Peek(httpsig.HeaderSignatureInput)), `alg="`+tt.name+`"`) {
+				t.Errorf("Signature-Input = %s", req.Header.Peek(httpsig.HeaderSignatureInput))
+			}
+
+			err = verifier.VerifyRequest(req)
+			if err != nil {
+				t.Fatalf("VerifyRequest() error = %v", err)
+			}
+
+			req.SetBodyString(`{"amount":999}`)
+			err = verifier.VerifyRequest(req)
+			if !errors.Is(err, httpsig.ErrContentDigest) {
+				t.Errorf("VerifyRequest() error = %v, want ErrContentDigest", err)
+			}
+
+			req.Header.SetMethod(fasthttp.MethodPut)
+			err = verifier.VerifyRequest(req)
+			if !errors.Is(err, httpsig.ErrInvalidSignature) {
+				t.Errorf("VerifyRequest() error = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func Test_VerifyResponse(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := httpsig.NewSigner(httpsig.Options{
+		KeyID: "partner",
+		Key:   privateKey,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	resp.SetStatusCode(http.StatusOK)
+	resp.SetBodyString(`{"status":"paid"}`)
+
+	err = signer.SignResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := httpsig.NewVerifier(httpsig.VerifyOptions{
+		Key: func(keyID string) (any, error) {
+			return publicKey, nil
+		},
+		RequiredComponents: []string{httpsig.ComponentStatus},
+	})
+
+	err = verifier.VerifyResponse(resp)
+	if err != nil {
+		t.Fatalf("VerifyResponse() error = %v", err)
+	}
+
+	resp.SetStatusCode(http.StatusAccepted)
+	err = verifier.VerifyResponse(resp)
+	if !errors.Is(err, httpsig.ErrInvalidSignature) {
+		t.Errorf("VerifyResponse() error = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func Test_Signer_Client(t *testing.T) {
+	secret := []byte("secret")
+	verifier := httpsig.NewVerifier(httpsig.VerifyOptions{
+		Key: func(keyID string) (any, error) {
+			return secret, nil
+		},
+	})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		req.Header.SetMethod(r.Method)
+		req.SetRequestURI("http://" + r.Host + r.URL.RequestURI())
+		for k, v := range r.Header {
+			req.Header.Set(k, strings.Join(v, ", "))
+		}
+		req.SetBody(body)
+
+		if err := verifier.VerifyRequest(req); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	signer, err := httpsig.NewSigner(httpsig.Options{
+		KeyID: "merchant",
+		Key:   secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithSigner(signer),
+	)
+
+	var result []byte
+	resp, err := client.Post(&reqx.Request{
+		URL:         ts.URL + "/payments?currency=THB",
+		Data:        map[string]int{"amount": 100},
+		ErrorResult: &result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Test_Signer_Client Error: %d %s", resp.StatusCode, result)
+	}
+}
+
+func Test_HMACSigner(t *testing.T) {
+	signer := httpsig.NewHMACSigner(httpsig.HMACOptions{
+		Key: []byte("secret"),
+		Now: func() time.Time {
+			return time.Unix(1700000000, 0)
+		},
+	})
+
+	req := newRequest(`{"amount":100}`)
+	defer fasthttp.ReleaseRequest(req)
+
+	err := signer.Sign(&reqx.RequestInfo{Request: req})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(req.Header.Peek("X-Timestamp")); got != "1700000000" {
+		t.Errorf("X-Timestamp = %s", got)
+	}
+	if got := string(req.Header.Peek("X-Signature")); len(got) != 64 {
+		t.Errorf("X-Signature = %s", got)
+	}
+}
+
+func Test_SignAndVerify_StructuredFieldStrings(t *testing.T) {
+	secret := []byte("secret")
+	signer, err := httpsig.NewSigner(httpsig.Options{
+		KeyID: `tenant "a"\key; one`,
+		Key:   secret,
+		Tag:   "app;v=1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := newRequest(`{"amount":100}`)
+	defer fasthttp.ReleaseRequest(req)
+
+	err = signer.SignRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := string(req.Header.Peek(httpsig.HeaderSignatureInput))
+	if !strings.Contains(input, `;keyid="tenant \"a\"\\key; one"`) || !strings.Contains(input, `;tag="app;v=1"`) {
+		t.Errorf("Signature-Input = %s", input)
+	}
+
+	verifier := httpsig.NewVerifier(httpsig.VerifyOptions{
+		Key: func(keyID string) (any, error) {
+			if keyID != `tenant "a"\key; one` {
+				return nil, errors.New("unknown key " + keyID)
+			}
+			return secret, nil
+		},
+	})
+	err = verifier.VerifyRequest(req)
+	if err != nil {
+		t.Errorf("VerifyRequest() error = %v", err)
+	}
+
+	for _, keyID := range []string{"schlüssel", "key\n1"} {
+		signer, err = httpsig.NewSigner(httpsig.Options{KeyID: keyID, Key: secret})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = signer.SignRequest(req)
+		if !errors.Is(err, httpsig.ErrInvalidString) {
+			t.Errorf("SignRequest(%q) error = %v, want ErrInvalidString", keyID, err)
+		}
+	}
+}
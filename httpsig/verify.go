@@ -0,0 +1,300 @@
+package httpsig
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	ErrNoSignature        = errors.New("httpsig: message is not signed")
+	ErrInvalidSignature   = errors.New("httpsig: invalid signature")
+	ErrSignatureExpired   = errors.New("httpsig: signature expired")
+	ErrContentDigest      = errors.New("httpsig: content digest does not match body")
+	ErrMissingComponent   = errors.New("httpsig: required component is not covered")
+	ErrMalformedSignature = errors.New("httpsig: malformed signature header")
+)
+
+type VerifyOptions struct {
+	// Key returns the verification key for a keyid: a []byte for hmac-sha256,
+	// an ed25519.PublicKey or an *ecdsa.PublicKey.
+	Key func(keyID string) (any, error)
+	// Label selects the signature to verify. When empty the first signature is used.
+	Label string
+	// RequiredComponents must all be covered by the signature.
+	RequiredComponents []string
+	// MaxAge rejects signatures created longer ago when positive.
+	MaxAge time.Duration
+	Now    func() time.Time
+}
+
+type Verifier struct {
+	opts VerifyOptions
+}
+
+func NewVerifier(opts VerifyOptions) *Verifier {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Verifier{
+		opts: opts,
+	}
+}
+
+func (v *Verifier) VerifyRequest(req *fasthttp.Request) error {
+	return v.verify(&requestMessage{req: req})
+}
+
+func (v *Verifier) VerifyResponse(resp *fasthttp.Response) error {
+	return v.verify(&responseMessage{resp: resp})
+}
+
+func (v *Verifier) verify(msg message) error {
+	inputs, err := parseDictionary(strings.Join(msg.header(strings.ToLower(HeaderSignatureInput)), ", "))
+	if err != nil {
+		return err
+	}
+	signatures, err := parseDictionary(strings.Join(msg.header(strings.ToLower(HeaderSignature)), ", "))
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 || len(signatures) == 0 {
+		return ErrNoSignature
+	}
+
+	input := inputs[0]
+	if v.opts.Label != "" {
+		input, err = findMember(inputs, v.opts.Label)
+		if err != nil {
+			return err
+		}
+	}
+	signatureMember, err := findMember(signatures, input.label)
+	if err != nil {
+		return err
+	}
+
+	signature, err := parseByteSequence(signatureMember.value)
+	if err != nil {
+		return err
+	}
+	params, err := parseSignatureParams(input.value)
+	if err != nil {
+		return err
+	}
+
+	for _, required := range v.opts.RequiredComponents {
+		if !slices.Contains(params.components, required) {
+			return fmt.Errorf("%w: %s", ErrMissingComponent, required)
+		}
+	}
+
+	now := v.opts.Now()
+	if params.expires > 0 && now.Unix() > params.expires {
+		return ErrSignatureExpired
+	}
+	if v.opts.MaxAge > 0 && params.created > 0 && now.Sub(time.Unix(params.created, 0)) > v.opts.MaxAge {
+		return ErrSignatureExpired
+	}
+
+	key, err := v.opts.Key(params.keyID)
+	if err != nil {
+		return err
+	}
+
+	base, err := signatureBase(msg, params.components, input.value)
+	if err != nil {
+		return err
+	}
+	ok, err := verifyBase(key, params.algorithm, base, signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidSignature
+	}
+
+	if slices.Contains(params.components, ComponentContentDigest) {
+		return verifyContentDigest(msg)
+	}
+	return nil
+}
+
+func verifyContentDigest(msg message) error {
+	digests, err := parseDictionary(strings.Join(msg.header(ComponentContentDigest), ", "))
+	if err != nil {
+		return err
+	}
+	for _, digest := range digests {
+		if digest.label != DigestSHA256 && digest.label != DigestSHA512 {
+			continue
+		}
+		expected, err := ContentDigest(digest.label, msg.body())
+		if err != nil {
+			return err
+		}
+		if expected != digest.label+"="+digest.value {
+			return ErrContentDigest
+		}
+		return nil
+	}
+	return ErrContentDigest
+}
+
+type dictionaryMember struct {
+	label string
+	value string
+}
+
+func findMember(members []dictionaryMember, label string) (dictionaryMember, error) {
+	for _, member := range members {
+		if member.label == label {
+			return member, nil
+		}
+	}
+	return dictionaryMember{}, fmt.Errorf("%w: label %q not found", ErrNoSignature, label)
+}
+
+// parseDictionary splits a structured field dictionary into its members,
+// keeping each member value in its serialized form.
+func parseDictionary(s string) ([]dictionaryMember, error) {
+	var members []dictionaryMember
+	var inString, inParens bool
+	start := 0
+
+	add := func(member string) error {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			return nil
+		}
+		label, value, ok := strings.Cut(member, "=")
+		if !ok {
+			return ErrMalformedSignature
+		}
+		members = append(members, dictionaryMember{
+			label: strings.TrimSpace(label),
+			value: strings.TrimSpace(value),
+		})
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(':
+			inParens = true
+		case c == ')':
+			inParens = false
+		case c == ',' && !inParens:
+			err := add(s[start:i])
+			if err != nil {
+				return nil, err
+			}
+			start = i + 1
+		}
+	}
+	if inString || inParens {
+		return nil, ErrMalformedSignature
+	}
+	err := add(s[start:])
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func parseByteSequence(s string) ([]byte, error) {
+	if len(s) < 2 || s[0] != ':' || s[len(s)-1] != ':' {
+		return nil, ErrMalformedSignature
+	}
+	return base64.StdEncoding.DecodeString(s[1 : len(s)-1])
+}
+
+func parseSignatureParams(s string) (*signatureParams, error) {
+	if !strings.HasPrefix(s, "(") {
+		return nil, ErrMalformedSignature
+	}
+
+	params := &signatureParams{}
+	rest := s[1:]
+	for {
+		rest = strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(rest, ")") {
+			rest = rest[1:]
+			break
+		}
+		component, r, err := parseString(rest)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(r, ";") {
+			// Component parameters such as ;req or ;sf are not supported.
+			return nil, fmt.Errorf("%w: unsupported component parameters of %q", ErrMalformedSignature, component)
+		}
+		params.components = append(params.components, component)
+		rest = r
+	}
+
+	for rest != "" {
+		if rest[0] != ';' {
+			return nil, ErrMalformedSignature
+		}
+		rest = strings.TrimLeft(rest[1:], " ")
+		key, value := rest, ""
+		if i := strings.IndexAny(rest, "=;"); i >= 0 {
+			key, rest = rest[:i], rest[i:]
+		} else {
+			rest = ""
+		}
+		if strings.HasPrefix(rest, "=") {
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				var err error
+				value, rest, err = parseString(rest)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				value = rest
+				if i := strings.IndexByte(rest, ';'); i >= 0 {
+					value, rest = rest[:i], rest[i:]
+				} else {
+					rest = ""
+				}
+			}
+		}
+
+		var err error
+		switch key {
+		case "created":
+			params.created, err = strconv.ParseInt(value, 10, 64)
+		case "expires":
+			params.expires, err = strconv.ParseInt(value, 10, 64)
+		case "nonce":
+			params.nonce = value
+		case "keyid":
+			params.keyID = value
+		case "alg":
+			params.algorithm = value
+		case "tag":
+			params.tag = value
+		}
+		if err != nil {
+			return nil, ErrMalformedSignature
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,135 @@
+package reqx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrJSONPathNotFound = errors.New("reqx: json path not found")
+
+// JSONArray streams the response of request and calls fn with every element of the array at path decoded
+// into a T with the JSON codec of the client. It stops at the first error of the request, an element or fn.
+func JSONArray[T any](ctx context.Context, client Client, method string, request *Request, path string, fn func(item T) error) error {
+	var err error
+	JSONArraySeq[T](ctx, client, method, request, path)(func(item T, itemErr error) bool {
+		if itemErr != nil {
+			err = itemErr
+			return false
+		}
+		err = fn(item)
+		return err == nil
+	})
+	return err
+}
+
+// JSONArraySeq returns an iterator, an iter.Seq2[T, error], over the elements of the array at path in the
+// streamed response of request. path is a dot separated list of object keys, e.g. "data.items",
+// and empty for a top-level array. Only one element is held in memory at a time.
+// A failed request, an unsuccessful status code (ErrUnexpectedStatus), a missing path (ErrJSONPathNotFound)
+// or an undecodable element yield an error and end the iteration.
+func JSONArraySeq[T any](ctx context.Context, client Client, method string, request *Request, path string) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		var zero T
+		resp, err := streamSuccess(ctx, client, method, request)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Close()
+
+		codec := resp.jsonCodec()
+		dec := json.NewDecoder(resp.Body)
+		err = findJSONArray(dec, path)
+		for err == nil && dec.More() {
+			var raw json.RawMessage
+			err = dec.Decode(&raw)
+			if err != nil {
+				break
+			}
+			var item T
+			err = codec.Unmarshal(raw, &item)
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+
+		if ctx != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// findJSONArray advances dec into the array at path, skipping the values of other keys.
+func findJSONArray(dec *json.Decoder, path string) error {
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			err := findJSONKey(dec, key)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, path)
+			}
+		}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("%w: %s is not an array", ErrJSONPathNotFound, path)
+	}
+	return nil
+}
+
+func findJSONKey(dec *json.Decoder, key string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return ErrJSONPathNotFound
+	}
+	for dec.More() {
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == key {
+			return nil
+		}
+		err = skipJSONValue(dec)
+		if err != nil {
+			return err
+		}
+	}
+	return ErrJSONPathNotFound
+}
+
+// skipJSONValue reads the next value token by token, so large values are not buffered.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
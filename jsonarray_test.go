@@ -0,0 +1,81 @@
+package reqx_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func jsonArrayHandler(ctx *fasthttp.RequestCtx) {
+	ctx.SetContentType(reqx.HeaderContentTypeJson)
+	switch string(ctx.Path()) {
+	case "/catalog":
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString(`{"meta":{"skip":[1,[2],{"items":[0]}],"next":null},"data":{"total":10000,"items":[`)
+			for i := 1; i <= 10000; i++ {
+				if i > 1 {
+					_, _ = w.WriteString(",")
+				}
+				_, _ = fmt.Fprintf(w, `{"id":%d}`, i)
+				if i%1000 == 0 {
+					_ = w.Flush()
+				}
+			}
+			_, _ = w.WriteString(`]},"tail":true}`)
+		})
+	case "/list":
+		ctx.SetBodyString(`[{"id":1},{"id":2}]`)
+	default:
+		ctx.SetBodyString(`{"data":{"items":{"id":1}}}`)
+	}
+}
+
+func Test_JSONArray(t *testing.T) {
+	unmarshalCalls := 0
+	client := reqx.New(
+		reqx.WithHandler(jsonArrayHandler),
+		reqx.WithCodec(reqx.NewJsonCodec(gojson.Marshal, func(data []byte, v interface{}) error {
+			unmarshalCalls++
+			return gojson.Unmarshal(data, v)
+		})),
+	)
+
+	sum := 0
+	err := reqx.JSONArray(context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://catalog.local/catalog"}, "data.items", func(hit Hit) error {
+		sum += hit.ID
+		return nil
+	})
+	if err != nil || sum != 50005000 || unmarshalCalls != 10000 {
+		t.Errorf("Test_JSONArray Error: sum %d, calls %d, %v", sum, unmarshalCalls, err)
+	}
+
+	var ids []int
+	reqx.JSONArraySeq[Hit](context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://catalog.local/list"}, "")(func(hit Hit, err error) bool {
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+		ids = append(ids, hit.ID)
+		return true
+	})
+	if fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Test_JSONArray Error: %v", ids)
+	}
+
+	for _, path := range []string{"data.missing", "data.items"} {
+		err = reqx.JSONArray(context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://catalog.local/object"}, path, func(hit Hit) error {
+			return nil
+		})
+		if !errors.Is(err, reqx.ErrJSONPathNotFound) {
+			t.Errorf("Test_JSONArray Error: %s %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package reqx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	LogRedacted           = "[REDACTED]"
+	LogUndecodableBody    = "[UNDECODABLE]"
+	defaultLogMaxBodySize = 4096
+	logTruncatedSuffix    = "...(truncated)"
+)
+
+// DefaultLogRedactHeaders are always redacted by the logger.
+var DefaultLogRedactHeaders = []string{
+	HeaderAuthorization,
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+// Error classes of log records.
+const (
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCanceled    = "canceled"
+	ErrorClassNetwork     = "network"
+	ErrorClassClientError = "client_error"
+	ErrorClassServerError = "server_error"
+	ErrorClassStatus      = "unexpected_status"
+)
+
+type LogOption struct {
+	// Level of records of successful requests. Unsuccessful status codes are logged at warn
+	// and transport errors at error level.
+	Level slog.Level
+	// LogHeaders adds request and response headers.
+	LogHeaders bool
+	// LogRequestBody and LogResponseBody add bodies capped at MaxBodySize bytes.
+	LogRequestBody  bool
+	LogResponseBody bool
+	MaxBodySize     int
+	// RedactHeaders are redacted in addition to DefaultLogRedactHeaders.
+	RedactHeaders     []string
+	RedactQueryParams []string
+	// RedactJSONFields are redacted at any depth of JSON bodies.
+	RedactJSONFields []string
+}
+
+type LogOptions func(opts *LogOption)
+
+func WithLogLevel(level slog.Level) LogOptions {
+	return func(opts *LogOption) {
+		opts.Level = level
+	}
+}
+
+func WithLogHeaders() LogOptions {
+	return func(opts *LogOption) {
+		opts.LogHeaders = true
+	}
+}
+
+// WithLogBodies logs request and response bodies up to maxSize bytes. A maxSize of 0 uses 4 KiB.
+func WithLogBodies(maxSize int) LogOptions {
+	return func(opts *LogOption) {
+		opts.LogRequestBody = true
+		opts.LogResponseBody = true
+		opts.MaxBodySize = maxSize
+	}
+}
+
+func WithLogRedactHeaders(headers ...string) LogOptions {
+	return func(opts *LogOption) {
+		opts.RedactHeaders = append(opts.RedactHeaders, headers...)
+	}
+}
+
+func WithLogRedactQueryParams(params ...string) LogOptions {
+	return func(opts *LogOption) {
+		opts.RedactQueryParams = append(opts.RedactQueryParams, params...)
+	}
+}
+
+func WithLogRedactJSONFields(fields ...string) LogOptions {
+	return func(opts *LogOption) {
+		opts.RedactJSONFields = append(opts.RedactJSONFields, fields...)
+	}
+}
+
+// WithLogger logs one record per sent request with method, url, status, duration, attempt and error class.
+func WithLogger(logger *slog.Logger, opts ...LogOptions) ClientOptions {
+	return func(opt *ClientOption) {
+		opt.Logger = logger
+		opt.LogOptions = append(opt.LogOptions, opts...)
+	}
+}
+
+type logTransport struct {
+	next   Transport
+	logger *slog.Logger
+	opt    *LogOption
+}
+
+func newLogTransport(next Transport, logger *slog.Logger, opts []LogOptions) *logTransport {
+	opt := &LogOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.MaxBodySize <= 0 {
+		opt.MaxBodySize = defaultLogMaxBodySize
+	}
+	opt.RedactHeaders = append(slices.Clone(DefaultLogRedactHeaders), opt.RedactHeaders...)
+
+	return &logTransport{
+		next:   next,
+		logger: logger,
+		opt:    opt,
+	}
+}
+
+func (t *logTransport) Do(req *RequestInfo, resp *fasthttp.Response) error {
+	start := time.Now()
+	err := t.next.Do(req, resp)
+	duration := time.Since(start)
+
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	level := t.opt.Level
+	attrs := []slog.Attr{
+		slog.String("method", string(req.Header.Method())),
+		slog.String("url", t.redactURL(req.URI())),
+	}
+	if err == nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode()))
+	}
+	attrs = append(attrs,
+		slog.Duration("duration", duration),
+		slog.Int("attempt", req.Attempt),
+	)
+
+	errorClass := ""
+	switch {
+	case err != nil:
+		level = slog.LevelError
+		errorClass = logErrorClass(err)
+		attrs = append(attrs, slog.String("error", err.Error()))
+	case !req.IsSuccess(resp.StatusCode()):
+		level = slog.LevelWarn
+		errorClass = statusErrorClass(resp.StatusCode())
+	}
+	if errorClass != "" {
+		attrs = append(attrs, slog.String("error_class", errorClass))
+	}
+
+	if !t.logger.Enabled(ctx, level) {
+		return err
+	}
+
+	if t.opt.LogHeaders {
+		attrs = append(attrs, slog.Any("request_headers", t.headers(req.Header.VisitAll)))
+		if err == nil {
+			attrs = append(attrs, slog.Any("response_headers", t.headers(resp.Header.VisitAll)))
+		}
+	}
+	if t.opt.LogRequestBody && len(req.Body()) > 0 {
+		attrs = append(attrs, slog.String("request_body", t.body(req.Header.ContentType(), req.Header.Peek(HeaderContentEncoding), req.Body())))
+	}
+	if t.opt.LogResponseBody && err == nil && !resp.IsBodyStream() && len(resp.Body()) > 0 {
+		attrs = append(attrs, slog.String("response_body", t.body(resp.Header.ContentType(), resp.Header.Peek(HeaderContentEncoding), resp.Body())))
+	}
+
+	t.logger.LogAttrs(ctx, level, "reqx request", attrs...)
+	return err
+}
+
+func (t *logTransport) headers(visitAll func(f func(key, value []byte))) map[string]string {
+	headers := map[string]string{}
+	visitAll(func(key, value []byte) {
+		name := string(key)
+		if containsFold(t.opt.RedactHeaders, name) {
+			headers[name] = LogRedacted
+			return
+		}
+		headers[name] = string(value)
+	})
+	return headers
+}
+
+// body returns body decompressed, redacted and capped at MaxBodySize.
+func (t *logTransport) body(contentType []byte, contentEncoding []byte, body []byte) string {
+	body, err := decodeBody(string(contentEncoding), body, DefaultMaxDecompressedSize)
+	if err != nil {
+		return LogUndecodableBody
+	}
+	if len(t.opt.RedactJSONFields) > 0 && isJSON(contentType, body) {
+		var v any
+		if gojson.Unmarshal(body, &v) == nil {
+			redactJSON(v, t.opt.RedactJSONFields)
+			if redacted, err := gojson.Marshal(v); err == nil {
+				body = redacted
+			}
+		} else {
+			// Invalid JSON could expose the fields to redact.
+			return LogRedacted
+		}
+	}
+	if len(body) > t.opt.MaxBodySize {
+		// Back up to the start of a split UTF-8 character.
+		n := t.opt.MaxBodySize
+		for i := 1; n > 0 && i < utf8.UTFMax && !utf8.RuneStart(body[n]); i++ {
+			n--
+		}
+		return string(body[:n]) + logTruncatedSuffix
+	}
+	return string(body)
+}
+
+func (t *logTransport) redactURL(uri *fasthttp.URI) string {
+	if len(t.opt.RedactQueryParams) == 0 {
+		return uri.String()
+	}
+
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+	uri.CopyTo(u)
+
+	args := u.QueryArgs()
+	for _, key := range t.opt.RedactQueryParams {
+		if args.Has(key) {
+			args.Set(key, LogRedacted)
+		}
+	}
+	return u.String()
+}
+
+func redactJSON(v any, fields []string) {
+	switch value := v.(type) {
+	case map[string]any:
+		for k, child := range value {
+			if containsFold(fields, k) {
+				value[k] = LogRedacted
+				continue
+			}
+			redactJSON(child, fields)
+		}
+	case []any:
+		for _, child := range value {
+			redactJSON(child, fields)
+		}
+	}
+}
+
+func isJSON(contentType []byte, body []byte) bool {
+	if strings.Contains(strings.ToLower(string(contentType)), "json") {
+		return true
+	}
+	trimmed := strings.TrimSpace(string(body[:min(len(body), 64)]))
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+func logErrorClass(err error) string {
+	switch {
+	case errors.Is(err, fasthttp.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	}
+	return ErrorClassNetwork
+}
+
+func statusErrorClass(statusCode int) string {
+	switch {
+	case statusCode >= http.StatusBadRequest && statusCode < http.StatusInternalServerError:
+		return ErrorClassClientError
+	case statusCode >= http.StatusInternalServerError:
+		return ErrorClassServerError
+	}
+	return ErrorClassStatus
+}
+
+func containsFold(values []string, s string) bool {
+	return slices.ContainsFunc(values, func(v string) bool {
+		return strings.EqualFold(v, s)
+	})
+}
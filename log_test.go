@@ -0,0 +1,165 @@
+package reqx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		record := map[string]any{}
+		err := json.Unmarshal([]byte(line), &record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func Test_WithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	client := reqx.New(
+		reqx.WithHeaders(reqx.Headers{reqx.HeaderAuthorization: "Bearer secret"}),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) == "/missing" {
+				ctx.SetStatusCode(http.StatusNotFound)
+				return
+			}
+			ctx.Response.Header.Set("Set-Cookie", "session=secret")
+			ctx.SetContentType(reqx.HeaderContentTypeJson)
+			ctx.SetBodyString(`{"user":{"name":"reqx","token":"secret"},"items":[{"password":"secret"}],"data":"` + strings.Repeat("x", 100) + `"}`)
+		}),
+		reqx.WithLogger(logger,
+			reqx.WithLogHeaders(),
+			reqx.WithLogBodies(80),
+			reqx.WithLogRedactQueryParams("apiKey"),
+			reqx.WithLogRedactJSONFields("password", "token"),
+		),
+	)
+
+	_, err := client.Post(&reqx.Request{
+		URL:  "http://api.local/login?apiKey=secret&page=1",
+		Data: map[string]string{"username": "reqx", "password": "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Get(&reqx.Request{URL: "http://api.local/missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(buf.String(), "secret") {
+		t.Errorf("Test_WithLogger Error: secret in log\n%s", buf.String())
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("Test_WithLogger Error: %d records", len(records))
+	}
+	record := records[0]
+	if record["level"] != "INFO" || record["method"] != http.MethodPost || record["status"] != float64(200) || record["attempt"] != float64(0) ||
+		!strings.Contains(record["url"].(string), "apiKey=%5BREDACTED%5D&page=1") || record["error_class"] != nil || record["duration"] == nil {
+		t.Errorf("Test_WithLogger Error: record %v", record)
+	}
+	if record["request_body"] != `{"password":"[REDACTED]","username":"reqx"}` ||
+		!strings.HasSuffix(record["response_body"].(string), "...(truncated)") {
+		t.Errorf("Test_WithLogger Error: bodies %v %v", record["request_body"], record["response_body"])
+	}
+	requestHeaders := record["request_headers"].(map[string]any)
+	if requestHeaders[reqx.HeaderAuthorization] != reqx.LogRedacted {
+		t.Errorf("Test_WithLogger Error: headers %v", requestHeaders)
+	}
+
+	if records[1]["level"] != "WARN" || records[1]["status"] != float64(404) || records[1]["error_class"] != reqx.ErrorClassClientError {
+		t.Errorf("Test_WithLogger Error: record %v", records[1])
+	}
+}
+
+func Test_WithLogger_Compressed(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetContentType(reqx.HeaderContentTypeJson)
+			ctx.Response.Header.Set(reqx.HeaderContentEncoding, reqx.ContentEncodingGzip)
+			ctx.SetBody(fasthttp.AppendGzipBytes(nil, []byte(`{"name":"reqx","token":"secret"}`)))
+		}),
+		reqx.WithLogger(logger,
+			reqx.WithLogBodies(0),
+			reqx.WithLogRedactJSONFields("token"),
+		),
+	)
+
+	_, err := client.Post(&reqx.Request{
+		URL:         "http://api.local/login",
+		Data:        map[string]string{"password": "secret"},
+		Compression: &reqx.Compression{Encoding: reqx.ContentEncodingGzip},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("Test_WithLogger_Compressed Error: %d records", len(records))
+	}
+	if records[0]["request_body"] != `{"password":"secret"}` || records[0]["response_body"] != `{"name":"reqx","token":"[REDACTED]"}` {
+		t.Errorf("Test_WithLogger_Compressed Error: bodies %v %v", records[0]["request_body"], records[0]["response_body"])
+	}
+}
+
+func Test_WithLogger_Error(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	client := reqx.New(
+		reqx.WithTransport(reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+			return fasthttp.ErrTimeout
+		})),
+		reqx.WithLogger(logger),
+	)
+
+	_, err := client.Get(&reqx.Request{URL: "http://api.local/users"})
+	if err == nil {
+		t.Fatal("Test_WithLogger_Error Error: expected error")
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["level"] != "ERROR" || records[0]["error_class"] != reqx.ErrorClassTimeout || records[0]["status"] != nil {
+		t.Errorf("Test_WithLogger_Error Error: %v", records)
+	}
+}
+
+func Test_WithLogger_TruncateUTF8(t *testing.T) {
+	var buf bytes.Buffer
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetContentType("text/plain; charset=utf-8")
+			ctx.SetBodyString("ab" + strings.Repeat("ไทย", 10))
+		}),
+		reqx.WithLogger(slog.New(slog.NewJSONHandler(&buf, nil)), reqx.WithLogBodies(6)),
+	)
+
+	_, err := client.Get(&reqx.Request{URL: "http://api.local/text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["response_body"] != "abไ...(truncated)" {
+		t.Errorf("Test_WithLogger_TruncateUTF8 Error: %v", records)
+	}
+}
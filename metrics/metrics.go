@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+// Label names used by the collectors.
+const (
+	LabelMethod      = "method"
+	LabelHost        = "host"
+	LabelRoute       = "route"
+	LabelStatusClass = "status_class"
+)
+
+// StatusClassError is the status class of requests that failed without a response.
+const StatusClassError = "error"
+
+type Labels struct {
+	Method string
+	Host   string
+	Route  string
+	// StatusClass is 1xx to 5xx or StatusClassError. It is empty when the request starts.
+	StatusClass string
+}
+
+// Recorder receives request metrics. Implementations must be safe for concurrent use.
+// Metrics are recorded per attempt: a request sent again, e.g. to answer an authentication challenge,
+// is started and finished once per send, and duration is the time of the transport for that send only,
+// without the time of the Authenticator, the Signer and decoding the response.
+// Use ResponseInfo.TotalTime of reqx.WithOnRequestCompleted for the duration of whole requests.
+type Recorder interface {
+	// RequestStarted is called before every attempt of a request is sent.
+	RequestStarted(labels Labels)
+	// RequestFinished is called when the attempt completed or failed. Labels are those of RequestStarted
+	// with the status class set. For streamed responses it is called when the headers are read
+	// and responseSize is the Content-Length, 0 when unknown.
+	RequestFinished(labels Labels, duration time.Duration, requestSize int, responseSize int)
+}
+
+type Options struct {
+	// Route returns the route template label, e.g. /users/{id}. Without it the route label is empty,
+	// request paths are never used as labels.
+	Route func(req *reqx.RequestInfo) string
+}
+
+// WithMetrics records metrics of every attempt of the requests of the client.
+func WithMetrics(recorder Recorder, opts Options) reqx.ClientOptions {
+	return reqx.WithTransportMiddleware(NewMiddleware(recorder, opts))
+}
+
+// NewMiddleware returns a transport middleware that reports every send of a request to recorder,
+// timing the next transports of the chain.
+func NewMiddleware(recorder Recorder, opts Options) reqx.TransportMiddleware {
+	return func(next reqx.Transport) reqx.Transport {
+		return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+			labels := Labels{
+				Method: string(req.Header.Method()),
+				Host:   string(req.URI().Host()),
+			}
+			if opts.Route != nil {
+				labels.Route = opts.Route(req)
+			}
+
+			recorder.RequestStarted(labels)
+			start := time.Now()
+			err := next.Do(req, resp)
+			duration := time.Since(start)
+
+			if err != nil {
+				labels.StatusClass = StatusClassError
+				recorder.RequestFinished(labels, duration, len(req.Body()), 0)
+				return err
+			}
+			labels.StatusClass = StatusClass(resp.StatusCode())
+			responseSize := resp.Header.ContentLength()
+			if !resp.IsBodyStream() {
+				responseSize = len(resp.Body())
+			}
+			recorder.RequestFinished(labels, duration, len(req.Body()), max(responseSize, 0))
+			return nil
+		})
+	}
+}
+
+// StatusClass returns the class of statusCode, e.g. 2xx.
+func StatusClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return StatusClassError
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
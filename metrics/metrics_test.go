@@ -0,0 +1,107 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/metrics"
+)
+
+type finished struct {
+	labels       metrics.Labels
+	requestSize  int
+	responseSize int
+}
+
+type recorder struct {
+	mu       sync.Mutex
+	inFlight int
+	started  []metrics.Labels
+	finished []finished
+}
+
+func (r *recorder) RequestStarted(labels metrics.Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight++
+	r.started = append(r.started, labels)
+}
+
+func (r *recorder) RequestFinished(labels metrics.Labels, duration time.Duration, requestSize int, responseSize int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight--
+	r.finished = append(r.finished, finished{labels: labels, requestSize: requestSize, responseSize: responseSize})
+}
+
+func Test_Middleware(t *testing.T) {
+	rec := &recorder{}
+	inFlight := 0
+
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			rec.mu.Lock()
+			inFlight = rec.inFlight
+			rec.mu.Unlock()
+			if string(ctx.Path()) == "/missing" {
+				ctx.SetStatusCode(http.StatusNotFound)
+				return
+			}
+			ctx.SetBodyString(`{"name":"reqx"}`)
+		}),
+		metrics.WithMetrics(rec, metrics.Options{
+			Route: func(req *reqx.RequestInfo) string {
+				return "/users/{id}"
+			},
+		}),
+	)
+
+	_, err := client.Post(&reqx.Request{
+		URL:  "http://api.local/users/1",
+		Data: map[string]string{"name": "reqx"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Get(&reqx.Request{URL: "http://api.local/missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inFlight != 1 || rec.inFlight != 0 || len(rec.started) != 2 || rec.started[0].StatusClass != "" {
+		t.Errorf("Test_Middleware Error: in flight %d %d, started %+v", inFlight, rec.inFlight, rec.started)
+	}
+	want := []finished{
+		{labels: metrics.Labels{Method: http.MethodPost, Host: "api.local", Route: "/users/{id}", StatusClass: "2xx"}, requestSize: 15, responseSize: 15},
+		{labels: metrics.Labels{Method: http.MethodGet, Host: "api.local", Route: "/users/{id}", StatusClass: "4xx"}},
+	}
+	for i, f := range want {
+		if rec.finished[i] != f {
+			t.Errorf("Test_Middleware Error: finished %+v, want %+v", rec.finished[i], f)
+		}
+	}
+}
+
+func Test_Middleware_Error(t *testing.T) {
+	rec := &recorder{}
+	client := reqx.New(
+		reqx.WithTransport(reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+			return errors.New("connection refused")
+		})),
+		metrics.WithMetrics(rec, metrics.Options{}),
+	)
+
+	_, err := client.Get(&reqx.Request{URL: "http://api.local/users"})
+	if err == nil {
+		t.Fatal("Test_Middleware_Error Error: expected error")
+	}
+	if rec.inFlight != 0 || len(rec.finished) != 1 || rec.finished[0].labels.StatusClass != metrics.StatusClassError || rec.finished[0].labels.Route != "" {
+		t.Errorf("Test_Middleware_Error Error: %+v", rec.finished)
+	}
+}
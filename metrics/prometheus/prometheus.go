@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/dreamph/reqx/metrics"
+)
+
+const subsystem = "http_client"
+
+var (
+	requestLabels  = []string{metrics.LabelMethod, metrics.LabelHost, metrics.LabelRoute, metrics.LabelStatusClass}
+	inFlightLabels = []string{metrics.LabelMethod, metrics.LabelHost, metrics.LabelRoute}
+)
+
+type Options struct {
+	Namespace   string
+	ConstLabels prometheus.Labels
+	// DurationBuckets default to prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets default to 8 exponential buckets from 64 bytes to 1 MiB.
+	SizeBuckets []float64
+}
+
+// Collector is a metrics.Recorder and a prometheus.Collector. Register it with a prometheus.Registerer:
+//
+//	http_client_requests_total{method,host,route,status_class}
+//	http_client_request_duration_seconds{method,host,route,status_class}
+//	http_client_requests_in_flight{method,host,route}
+//	http_client_request_size_bytes{method,host,route,status_class}
+//	http_client_response_size_bytes{method,host,route,status_class}
+//
+// Requests are counted and timed per attempt, see metrics.Recorder.
+type Collector struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+func NewCollector(opts Options) *Collector {
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = prometheus.DefBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = prometheus.ExponentialBuckets(64, 4, 8)
+	}
+
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   subsystem,
+			Name:        "requests_total",
+			Help:        "Number of HTTP client requests.",
+			ConstLabels: opts.ConstLabels,
+		}, requestLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   subsystem,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of HTTP client request attempts, without authentication, signing and response decoding.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.DurationBuckets,
+		}, requestLabels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   subsystem,
+			Name:        "requests_in_flight",
+			Help:        "Number of HTTP client requests in flight.",
+			ConstLabels: opts.ConstLabels,
+		}, inFlightLabels),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   subsystem,
+			Name:        "request_size_bytes",
+			Help:        "Size of HTTP client request bodies.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.SizeBuckets,
+		}, requestLabels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Subsystem:   subsystem,
+			Name:        "response_size_bytes",
+			Help:        "Size of HTTP client response bodies.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.SizeBuckets,
+		}, requestLabels),
+	}
+}
+
+func (c *Collector) RequestStarted(labels metrics.Labels) {
+	c.inFlight.WithLabelValues(labels.Method, labels.Host, labels.Route).Inc()
+}
+
+func (c *Collector) RequestFinished(labels metrics.Labels, duration time.Duration, requestSize int, responseSize int) {
+	c.inFlight.WithLabelValues(labels.Method, labels.Host, labels.Route).Dec()
+
+	values := []string{labels.Method, labels.Host, labels.Route, labels.StatusClass}
+	c.requests.WithLabelValues(values...).Inc()
+	c.duration.WithLabelValues(values...).Observe(duration.Seconds())
+	c.requestSize.WithLabelValues(values...).Observe(float64(requestSize))
+	c.responseSize.WithLabelValues(values...).Observe(float64(responseSize))
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.duration.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.requestSize.Describe(ch)
+	c.responseSize.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.duration.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.requestSize.Collect(ch)
+	c.responseSize.Collect(ch)
+}
@@ -0,0 +1,60 @@
+package prometheus_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/metrics"
+	reqxprometheus "github.com/dreamph/reqx/metrics/prometheus"
+)
+
+func Test_Collector(t *testing.T) {
+	collector := reqxprometheus.NewCollector(reqxprometheus.Options{Namespace: "app"})
+	registry := prometheus.NewPedanticRegistry()
+	err := registry.Register(collector)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(http.StatusCreated)
+			ctx.SetBodyString("created")
+		}),
+		metrics.WithMetrics(collector, metrics.Options{}),
+	)
+	for i := 0; i < 2; i++ {
+		_, err = client.Post(&reqx.Request{
+			URL:  "http://api.local/users",
+			Data: reqx.Raw{Body: []byte("user")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP app_http_client_requests_in_flight Number of HTTP client requests in flight.
+# TYPE app_http_client_requests_in_flight gauge
+app_http_client_requests_in_flight{host="api.local",method="POST",route=""} 0
+# HELP app_http_client_requests_total Number of HTTP client requests.
+# TYPE app_http_client_requests_total counter
+app_http_client_requests_total{host="api.local",method="POST",route="",status_class="2xx"} 2
+`
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "app_http_client_requests_total", "app_http_client_requests_in_flight")
+	if err != nil {
+		t.Error(err)
+	}
+
+	count, err := testutil.GatherAndCount(registry,
+		"app_http_client_request_duration_seconds", "app_http_client_request_size_bytes", "app_http_client_response_size_bytes")
+	if err != nil || count != 3 {
+		t.Errorf("Test_Collector Error: %d histograms, %v", count, err)
+	}
+}
@@ -0,0 +1,55 @@
+package msgpack
+
+import (
+	"bytes"
+
+	vmsgpack "github.com/vmihailenco/msgpack/v5"
+
+	"github.com/dreamph/reqx"
+)
+
+const ContentType = "application/msgpack"
+
+// ContentTypes are the content types WithMsgpack registers the codec for.
+var ContentTypes = []string{ContentType, "application/x-msgpack", "application/vnd.msgpack"}
+
+// WithMsgpack registers the MessagePack codec for ContentTypes.
+func WithMsgpack() reqx.ClientOptions {
+	return reqx.WithCodec(NewCodec(), ContentTypes...)
+}
+
+// Body is a request body of data sent as application/msgpack.
+func Body(data interface{}) *reqx.Body {
+	return &reqx.Body{
+		ContentType: ContentType,
+		Data:        data,
+	}
+}
+
+type codec struct{}
+
+// NewCodec returns a MessagePack Codec. Struct fields without a msgpack tag use their json tag.
+func NewCodec() reqx.Codec {
+	return codec{}
+}
+
+func (codec) ContentType() string {
+	return ContentType
+}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := vmsgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	err := enc.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	dec := vmsgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
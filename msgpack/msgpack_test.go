@@ -0,0 +1,61 @@
+package msgpack_test
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+	vmsgpack "github.com/vmihailenco/msgpack/v5"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/msgpack"
+)
+
+type Reading struct {
+	Sensor string  `json:"sensor"`
+	Value  float64 `json:"value"`
+}
+
+func Test_Msgpack(t *testing.T) {
+	var contentType string
+	client := reqx.New(
+		msgpack.WithMsgpack(),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			var reading map[string]interface{}
+			err := vmsgpack.Unmarshal(ctx.Request.Body(), &reading)
+			if err != nil {
+				t.Error(err)
+			}
+			if reading["sensor"] != "temp" {
+				t.Errorf("Test_Msgpack Error: %v", reading)
+			}
+			contentType = string(ctx.Request.Header.ContentType())
+			reading["value"] = 21.5
+			body, err := vmsgpack.Marshal(reading)
+			if err != nil {
+				t.Error(err)
+			}
+			ctx.SetContentType(msgpack.ContentType)
+			ctx.SetBody(body)
+		}),
+	)
+
+	var result Reading
+	_, err := client.Post(&reqx.Request{
+		URL:     "http://gateway.local/readings",
+		Headers: reqx.Headers{reqx.HeaderContentType: "application/x-msgpack"},
+		Data:    &Reading{Sensor: "temp", Value: 20},
+		Result:  &result,
+	})
+	if err != nil || contentType != "application/x-msgpack" || result != (Reading{Sensor: "temp", Value: 21.5}) {
+		t.Errorf("Test_Msgpack Error: %s %+v, %v", contentType, result, err)
+	}
+
+	_, err = client.Post(&reqx.Request{
+		URL:    "http://gateway.local/readings",
+		Data:   msgpack.Body(&Reading{Sensor: "temp"}),
+		Result: &result,
+	})
+	if err != nil || contentType != msgpack.ContentType || result.Value != 21.5 {
+		t.Errorf("Test_Msgpack Error: %s %+v, %v", contentType, result, err)
+	}
+}
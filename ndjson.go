@@ -0,0 +1,96 @@
+package reqx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	HeaderContentTypeNDJSON = "application/x-ndjson"
+
+	// DefaultNDJSONMaxLineSize limits NDJSON lines to 1 MiB.
+	DefaultNDJSONMaxLineSize = 1 << 20
+)
+
+var ErrNDJSONLineTooLong = errors.New("reqx: ndjson line too long")
+
+type NDJSONOption struct {
+	// MaxLineSize is the maximum length of a line in bytes, DefaultNDJSONMaxLineSize when 0.
+	MaxLineSize int
+}
+
+type NDJSONOptions func(opts *NDJSONOption)
+
+func WithNDJSONMaxLineSize(size int) NDJSONOptions {
+	return func(opts *NDJSONOption) {
+		opts.MaxLineSize = size
+	}
+}
+
+// NDJSON streams the response of request and calls fn with every line decoded into a T
+// with the JSON codec of the client. It stops at the first error of the request, a line or fn.
+func NDJSON[T any](ctx context.Context, client Client, method string, request *Request, fn func(item T) error, opts ...NDJSONOptions) error {
+	var err error
+	NDJSONSeq[T](ctx, client, method, request, opts...)(func(item T, itemErr error) bool {
+		if itemErr != nil {
+			err = itemErr
+			return false
+		}
+		err = fn(item)
+		return err == nil
+	})
+	return err
+}
+
+// NDJSONSeq returns an iterator, an iter.Seq2[T, error], over the lines of the streamed response of request.
+// A failed request, an unsuccessful status code (ErrUnexpectedStatus) or an undecodable line yield an error
+// and end the iteration. Empty lines are skipped. Breaking the loop closes the response.
+func NDJSONSeq[T any](ctx context.Context, client Client, method string, request *Request, opts ...NDJSONOptions) func(yield func(T, error) bool) {
+	opt := &NDJSONOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.MaxLineSize <= 0 {
+		opt.MaxLineSize = DefaultNDJSONMaxLineSize
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		resp, err := streamSuccess(ctx, client, method, request)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer resp.Close()
+
+		codec := resp.jsonCodec()
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, min(4096, opt.MaxLineSize)), opt.MaxLineSize)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var item T
+			err = codec.Unmarshal(line, &item)
+			if !yield(item, err) || err != nil {
+				return
+			}
+		}
+
+		err = scanner.Err()
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("%w: more than %d bytes", ErrNDJSONLineTooLong, opt.MaxLineSize)
+		}
+		if ctx != nil && ctx.Err() != nil {
+			// Canceling closes the connection, so the read error is less useful.
+			err = ctx.Err()
+		}
+		if err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package reqx_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+type Hit struct {
+	ID int `json:"id"`
+}
+
+func ndjsonHandler(t *testing.T) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		switch string(ctx.Path()) {
+		case "/missing":
+			ctx.SetStatusCode(http.StatusNotFound)
+			ctx.SetContentType(reqx.HeaderContentTypeJson)
+			ctx.SetBodyString(`{"name":"index not found"}`)
+			return
+		case "/long":
+			ctx.SetContentType(reqx.HeaderContentTypeNDJSON)
+			ctx.SetBodyString(`{"id":1}` + "\n" + `{"id":"` + strings.Repeat("x", 100) + `"}` + "\n")
+			return
+		}
+
+		ctx.SetContentType(reqx.HeaderContentTypeNDJSON)
+		ctx.Response.Header.Set(reqx.HeaderContentEncoding, reqx.ContentEncodingGzip)
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			gw := gzip.NewWriter(w)
+			for i := 1; i <= 1000; i++ {
+				_, err := fmt.Fprintf(gw, "{\"id\":%d}\r\n", i)
+				if err != nil {
+					return
+				}
+				if i%100 == 0 {
+					_, _ = gw.Write([]byte("\n"))
+					_ = gw.Flush()
+					_ = w.Flush()
+				}
+			}
+			if err := gw.Close(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func Test_NDJSON(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(ndjsonHandler(t)))
+
+	sum := 0
+	err := reqx.NDJSON(context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://search.local/export"}, func(hit Hit) error {
+		sum += hit.ID
+		return nil
+	})
+	if err != nil || sum != 500500 {
+		t.Errorf("Test_NDJSON Error: sum %d, %v", sum, err)
+	}
+
+	stop := errors.New("stop")
+	count := 0
+	err = reqx.NDJSON(context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://search.local/export"}, func(hit Hit) error {
+		count++
+		if hit.ID == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || count != 10 {
+		t.Errorf("Test_NDJSON Error: count %d, %v", count, err)
+	}
+
+	var errorResult Data
+	err = reqx.NDJSON(context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://search.local/missing", ErrorResult: &errorResult}, func(hit Hit) error {
+		return nil
+	})
+	if !errors.Is(err, reqx.ErrUnexpectedStatus) || errorResult.Name != "index not found" {
+		t.Errorf("Test_NDJSON Error: %v %+v", err, errorResult)
+	}
+
+	err = reqx.NDJSON(context.Background(), struct{ reqx.Client }{client}, http.MethodGet, &reqx.Request{URL: "http://search.local/export"}, func(hit Hit) error {
+		return nil
+	})
+	if !errors.Is(err, reqx.ErrUnsupportedClient) {
+		t.Errorf("Test_NDJSON Error: %v", err)
+	}
+}
+
+func Test_NDJSONSeq(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(ndjsonHandler(t)))
+
+	var ids []int
+	reqx.NDJSONSeq[Hit](context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://search.local/export"})(func(hit Hit, err error) bool {
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+		ids = append(ids, hit.ID)
+		return len(ids) < 3
+	})
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		t.Errorf("Test_NDJSONSeq Error: %v", ids)
+	}
+
+	var errs []error
+	reqx.NDJSONSeq[Hit](context.Background(), client, http.MethodGet, &reqx.Request{URL: "http://search.local/long"}, reqx.WithNDJSONMaxLineSize(64))(func(hit Hit, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return true
+	})
+	if len(errs) != 1 || !errors.Is(errs[0], reqx.ErrNDJSONLineTooLong) {
+		t.Errorf("Test_NDJSONSeq Error: %v", errs)
+	}
+}
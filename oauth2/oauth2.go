@@ -0,0 +1,282 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gojson "github.com/goccy/go-json"
+
+	"github.com/dreamph/reqx"
+)
+
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypePassword          = "password"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+type AuthStyle int
+
+const (
+	// AuthStyleInHeader sends the client credentials with HTTP Basic authentication.
+	AuthStyleInHeader AuthStyle = iota
+	// AuthStyleInParams sends the client credentials as client_id and client_secret body parameters.
+	AuthStyleInParams
+)
+
+var ErrNoRefreshToken = errors.New("oauth2: token has no refresh token")
+
+// Error is an RFC 6749 section 5.2 error response.
+type Error struct {
+	StatusCode  int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+	URI         string `json:"error_uri,omitempty"`
+	Body        []byte `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("oauth2: token request failed with status %d: %s", e.StatusCode, e.Body)
+	}
+	msg := fmt.Sprintf("oauth2: %q", e.Code)
+	if e.Description != "" {
+		msg += " " + strconv.Quote(e.Description)
+	}
+	if e.URI != "" {
+		msg += " " + strconv.Quote(e.URI)
+	}
+	return msg
+}
+
+type Config struct {
+	// Client sends the token requests. When nil a default reqx client is used.
+	// It may be the client authenticated by this Config, token requests skip its Authenticator.
+	Client         reqx.Client
+	TokenURL       string
+	ClientID       string
+	ClientSecret   string
+	Scopes         []string
+	AuthStyle      AuthStyle
+	EndpointParams url.Values
+	// ExpiryDelta is how long before expiry a cached token is refreshed.
+	ExpiryDelta time.Duration
+}
+
+type tokenResponse struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresIn    expiresIn `json:"expires_in"`
+	Scope        string    `json:"scope"`
+}
+
+// expiresIn accepts both numbers and numeric strings, which some providers send.
+type expiresIn int64
+
+func (e *expiresIn) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*e = expiresIn(n)
+	return nil
+}
+
+func (c *Config) ClientCredentials(ctx context.Context) (*reqx.Token, error) {
+	values := url.Values{
+		"grant_type": {GrantTypeClientCredentials},
+	}
+	return c.retrieveToken(ctx, values)
+}
+
+func (c *Config) PasswordCredentials(ctx context.Context, username string, password string) (*reqx.Token, error) {
+	values := url.Values{
+		"grant_type": {GrantTypePassword},
+		"username":   {username},
+		"password":   {password},
+	}
+	return c.retrieveToken(ctx, values)
+}
+
+func (c *Config) Refresh(ctx context.Context, refreshToken string) (*reqx.Token, error) {
+	if refreshToken == "" {
+		return nil, ErrNoRefreshToken
+	}
+	values := url.Values{
+		"grant_type":    {GrantTypeRefreshToken},
+		"refresh_token": {refreshToken},
+	}
+	token, err := c.retrieveToken(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+	// Servers may omit the refresh token when it is not rotated.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// ClientCredentialsTokenSource returns a cached token source for the client credentials grant.
+func (c *Config) ClientCredentialsTokenSource() *reqx.RefreshTokenSource {
+	return reqx.NewRefreshTokenSource(c.ClientCredentials, c.ExpiryDelta)
+}
+
+// PasswordTokenSource returns a cached token source that logs in with the password grant
+// and afterwards renews the token with the refresh token grant.
+func (c *Config) PasswordTokenSource(username string, password string) *reqx.RefreshTokenSource {
+	return c.refreshingTokenSource(nil, func(ctx context.Context) (*reqx.Token, error) {
+		return c.PasswordCredentials(ctx, username, password)
+	})
+}
+
+// RefreshTokenSource returns a cached token source that starts from token and renews it with the
+// refresh token grant.
+func (c *Config) RefreshTokenSource(token *reqx.Token) *reqx.RefreshTokenSource {
+	return c.refreshingTokenSource(token, func(ctx context.Context) (*reqx.Token, error) {
+		return nil, ErrNoRefreshToken
+	})
+}
+
+func (c *Config) refreshingTokenSource(token *reqx.Token, login reqx.TokenSourceFunc) *reqx.RefreshTokenSource {
+	var mu sync.Mutex
+	var refreshToken string
+	if token != nil {
+		refreshToken = token.RefreshToken
+	}
+
+	first := token
+	return reqx.NewRefreshTokenSource(func(ctx context.Context) (*reqx.Token, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if first != nil {
+			t := first
+			first = nil
+			if t.AccessToken != "" && (t.Expiry.IsZero() || time.Now().Before(t.Expiry)) {
+				return t, nil
+			}
+		}
+
+		var t *reqx.Token
+		var err error
+		if refreshToken != "" {
+			t, err = c.Refresh(ctx, refreshToken)
+			var oauthErr *Error
+			if errors.As(err, &oauthErr) && oauthErr.Code == "invalid_grant" {
+				t, err = login(ctx)
+			}
+		} else {
+			t, err = login(ctx)
+		}
+		if err != nil {
+			return nil, err
+		}
+		refreshToken = t.RefreshToken
+		return t, nil
+	}, c.ExpiryDelta)
+}
+
+// Authenticator returns a reqx.Authenticator that uses the client credentials grant.
+func (c *Config) Authenticator() reqx.Authenticator {
+	return reqx.TokenAuth(c.ClientCredentialsTokenSource())
+}
+
+func (c *Config) retrieveToken(ctx context.Context, values url.Values) (*reqx.Token, error) {
+	if len(c.Scopes) > 0 {
+		values.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	for k, v := range c.EndpointParams {
+		values[k] = v
+	}
+
+	headers := reqx.Headers{
+		"Accept": reqx.HeaderContentTypeJson,
+	}
+	if c.AuthStyle == AuthStyleInParams {
+		values.Set("client_id", c.ClientID)
+		if c.ClientSecret != "" {
+			values.Set("client_secret", c.ClientSecret)
+		}
+	} else {
+		credentials := url.QueryEscape(c.ClientID) + ":" + url.QueryEscape(c.ClientSecret)
+		headers[reqx.HeaderAuthorization] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+
+	var body []byte
+	resp, err := c.client().Post(&reqx.Request{
+		Context: reqx.WithoutAuthentication(ctx),
+		URL:     c.TokenURL,
+		Data: &reqx.FormUrlEncoded{
+			Values: &values,
+		},
+		Headers:     headers,
+		Result:      &body,
+		ErrorResult: &body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, parseError(resp.StatusCode, body)
+	}
+	return parseToken(resp.StatusCode, body)
+}
+
+func (c *Config) client() reqx.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	return defaultClient()
+}
+
+var defaultClient = sync.OnceValue(func() reqx.Client {
+	return reqx.New()
+})
+
+func parseToken(statusCode int, body []byte) (*reqx.Token, error) {
+	tr := &tokenResponse{}
+	err := gojson.Unmarshal(body, tr)
+	if err != nil {
+		return nil, fmt.Errorf("oauth2: cannot parse token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		// Some servers answer errors with a 200 status.
+		oauthErr := parseError(statusCode, body)
+		if oauthErr.Code != "" {
+			return nil, oauthErr
+		}
+		return nil, errors.New("oauth2: server response missing access_token")
+	}
+
+	token := &reqx.Token{
+		AccessToken:  tr.AccessToken,
+		TokenType:    tr.TokenType,
+		RefreshToken: tr.RefreshToken,
+	}
+	if tr.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
+
+func parseError(statusCode int, body []byte) *Error {
+	e := &Error{}
+	_ = gojson.Unmarshal(body, e)
+	e.StatusCode = statusCode
+	e.Body = body
+	return e
+}
@@ -0,0 +1,204 @@
+package oauth2_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/oauth2"
+)
+
+func newTokenServer(tokenCount *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api" {
+			if r.Header.Get(reqx.HeaderAuthorization) != fmt.Sprintf("Bearer token-%d", tokenCount.Load()) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+
+		_ = r.ParseForm()
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID == "" {
+			clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+		}
+		if clientID != "client" || clientSecret != "secret" {
+			w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"bad credentials"}`))
+			return
+		}
+
+		switch r.PostFormValue("grant_type") {
+		case oauth2.GrantTypeClientCredentials:
+		case oauth2.GrantTypePassword:
+			if r.PostFormValue("username") != "user" || r.PostFormValue("password") != "pass" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+		case oauth2.GrantTypeRefreshToken:
+			if r.PostFormValue("refresh_token") != "refresh" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"unsupported_grant_type"}`))
+			return
+		}
+
+		n := tokenCount.Add(1)
+		w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":"3600","refresh_token":"refresh","scope":%q}`, n, r.PostFormValue("scope"))
+	}))
+}
+
+func Test_ClientCredentials(t *testing.T) {
+	var tokenCount atomic.Int32
+	ts := newTokenServer(&tokenCount)
+	defer ts.Close()
+
+	config := &oauth2.Config{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}
+
+	token, err := config.ClientCredentials(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token-1" || token.Type() != "Bearer" || token.RefreshToken != "refresh" {
+		t.Errorf("Test_ClientCredentials Error: %+v", token)
+	}
+	if time.Until(token.Expiry) < 59*time.Minute {
+		t.Errorf("Test_ClientCredentials Error: expiry %v", token.Expiry)
+	}
+}
+
+func Test_ClientCredentials_Error(t *testing.T) {
+	var tokenCount atomic.Int32
+	ts := newTokenServer(&tokenCount)
+	defer ts.Close()
+
+	config := &oauth2.Config{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "wrong",
+		AuthStyle:    oauth2.AuthStyleInParams,
+	}
+
+	_, err := config.ClientCredentials(context.Background())
+	var oauthErr *oauth2.Error
+	if !errors.As(err, &oauthErr) {
+		t.Fatalf("Test_ClientCredentials_Error Error: %v", err)
+	}
+	if oauthErr.StatusCode != http.StatusUnauthorized || oauthErr.Code != "invalid_client" || oauthErr.Description != "bad credentials" {
+		t.Errorf("Test_ClientCredentials_Error Error: %+v", oauthErr)
+	}
+}
+
+func Test_PasswordTokenSource_Refresh(t *testing.T) {
+	var tokenCount atomic.Int32
+	ts := newTokenServer(&tokenCount)
+	defer ts.Close()
+
+	config := &oauth2.Config{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	source := config.PasswordTokenSource("user", "pass")
+
+	token, err := source.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err = source.Refresh(context.Background(), token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token-2" {
+		t.Errorf("Test_PasswordTokenSource_Refresh Error: %+v", token)
+	}
+}
+
+func Test_Authenticator(t *testing.T) {
+	var tokenCount atomic.Int32
+	ts := newTokenServer(&tokenCount)
+	defer ts.Close()
+
+	config := &oauth2.Config{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(config.Authenticator()),
+	)
+
+	for i := 0; i < 2; i++ {
+		var result []byte
+		resp, err := client.Get(&reqx.Request{
+			URL:    ts.URL + "/api",
+			Result: &result,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || string(result) != "ok" {
+			t.Errorf("Test_Authenticator Error: status %d", resp.StatusCode)
+		}
+	}
+
+	if tokenCount.Load() != 1 {
+		t.Errorf("Test_Authenticator Error: fetched %d tokens, want 1", tokenCount.Load())
+	}
+}
+
+func Test_Authenticator_SameClient(t *testing.T) {
+	var tokenCount atomic.Int32
+	ts := newTokenServer(&tokenCount)
+	defer ts.Close()
+
+	config := &oauth2.Config{
+		TokenURL:     ts.URL + "/token",
+		ClientID:     "client",
+		ClientSecret: "secret",
+	}
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithAuthenticator(config.Authenticator()),
+	)
+	config.Client = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var result []byte
+	resp, err := client.Get(&reqx.Request{
+		Context: ctx,
+		URL:     ts.URL + "/api",
+		Result:  &result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(result) != "ok" || tokenCount.Load() != 1 {
+		t.Errorf("Test_Authenticator_SameClient Error: status %d, fetched %d tokens", resp.StatusCode, tokenCount.Load())
+	}
+}
@@ -0,0 +1,247 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/valyala/fasthttp"
+	gootel "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/dreamph/reqx"
+)
+
+const instrumentationName = "github.com/dreamph/reqx/otel"
+
+type Options struct {
+	// TracerProvider defaults to the global tracer provider.
+	TracerProvider trace.TracerProvider
+	// Propagator defaults to W3C trace context (traceparent and tracestate).
+	Propagator propagation.TextMapPropagator
+	// URLTemplate returns the low-cardinality template of the request path, e.g. /users/{id}.
+	// It is recorded as url.template and used in the span name.
+	URLTemplate func(req *reqx.RequestInfo) string
+	// Attributes returns extra attributes for the span of req.
+	Attributes func(req *reqx.RequestInfo) []attribute.KeyValue
+	// IncludeURLQuery records the query string in url.full. It is left out by default,
+	// as query strings may carry credentials. User info is never recorded.
+	IncludeURLQuery bool
+}
+
+// WithTracing traces every request of the client. The span starts and the trace context is injected into
+// the request headers before the Signer runs, so signatures cover it. The span ends after the attempt,
+// also when the Signer fails or a transport middleware answers without sending the request.
+func WithTracing(opts Options) reqx.ClientOptions {
+	t := newTracing(opts)
+	return func(o *reqx.ClientOption) {
+		reqx.WithBeforeSign(t.inject)(o)
+		reqx.WithAfterAttempt(t.finish)(o)
+	}
+}
+
+// NewMiddleware returns a transport middleware that starts a client span for every send of a request
+// as a child of the request context and injects the trace context into the request headers.
+// The span status is an error when the transport fails or the status code is not a success for the request.
+// The headers are injected after the Signer ran, use WithTracing with a Signer.
+func NewMiddleware(opts Options) reqx.TransportMiddleware {
+	return newTracing(opts).middleware
+}
+
+type tracing struct {
+	opts   Options
+	tracer trace.Tracer
+}
+
+func newTracing(opts Options) *tracing {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = gootel.GetTracerProvider()
+	}
+	if opts.Propagator == nil {
+		opts.Propagator = propagation.TraceContext{}
+	}
+	return &tracing{
+		opts:   opts,
+		tracer: opts.TracerProvider.Tracer(instrumentationName),
+	}
+}
+
+type startedSpanKey struct{}
+
+// startedSpan is the span started by inject and ended by finish.
+type startedSpan struct {
+	tracing *tracing
+	parent  context.Context
+	span    trace.Span
+}
+
+// inject starts the span of req and injects its trace context before req is signed.
+func (t *tracing) inject(req *reqx.RequestInfo) {
+	parent := req.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, span := t.start(parent, req)
+	req.Context = context.WithValue(ctx, startedSpanKey{}, &startedSpan{
+		tracing: t,
+		parent:  parent,
+		span:    span,
+	})
+}
+
+// finish ends the span started by inject, if any, with the outcome of the attempt.
+func (t *tracing) finish(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+	started, ok := startedSpanOf(req.Context)
+	if !ok || started.tracing != t {
+		return
+	}
+	req.Context = started.parent
+	t.end(started.span, req, resp.Response, resp.Err)
+}
+
+func (t *tracing) start(parent context.Context, req *reqx.RequestInfo) (context.Context, trace.Span) {
+	method := string(req.Header.Method())
+	spanName := method
+	attrs := requestAttributes(req, t.opts.IncludeURLQuery)
+	if t.opts.URLTemplate != nil {
+		if template := t.opts.URLTemplate(req); template != "" {
+			spanName += " " + template
+			attrs = append(attrs, semconv.URLTemplate(template))
+		}
+	}
+	if t.opts.Attributes != nil {
+		attrs = append(attrs, t.opts.Attributes(req)...)
+	}
+
+	ctx, span := t.tracer.Start(parent, spanName,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	t.opts.Propagator.Inject(ctx, &headerCarrier{header: &req.Header})
+	return ctx, span
+}
+
+func (t *tracing) middleware(next reqx.Transport) reqx.Transport {
+	return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+		parent := req.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		var span trace.Span
+		req.Context, span = t.start(parent, req)
+
+		err := next.Do(req, resp)
+		req.Context = parent
+		t.end(span, req, resp, err)
+		return err
+	})
+}
+
+// end records the outcome of the attempt on span and ends it.
+func (t *tracing) end(span trace.Span, req *reqx.RequestInfo, resp *fasthttp.Response, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(semconv.ErrorTypeKey.String(errorType(err)))
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	statusCode := resp.StatusCode()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(statusCode))
+	if !resp.IsBodyStream() {
+		// Reading a streamed body would wait for its end.
+		span.SetAttributes(semconv.HTTPResponseBodySize(len(resp.Body())))
+	}
+	if !req.IsSuccess(statusCode) {
+		span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(statusCode)))
+		span.SetStatus(codes.Error, "unexpected status code "+strconv.Itoa(statusCode))
+	}
+}
+
+func startedSpanOf(ctx context.Context) (*startedSpan, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	started, ok := ctx.Value(startedSpanKey{}).(*startedSpan)
+	return started, ok
+}
+
+func requestAttributes(req *reqx.RequestInfo, includeQuery bool) []attribute.KeyValue {
+	uri := req.URI()
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(string(req.Header.Method())),
+		semconv.URLFull(fullURL(uri, includeQuery)),
+		semconv.HTTPRequestBodySize(len(req.Body())),
+	}
+
+	host := string(uri.Host())
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		attrs = append(attrs, semconv.ServerAddress(h))
+		if port, err := strconv.Atoi(p); err == nil {
+			attrs = append(attrs, semconv.ServerPort(port))
+		}
+	} else {
+		attrs = append(attrs, semconv.ServerAddress(host))
+		if string(uri.Scheme()) == "https" {
+			attrs = append(attrs, semconv.ServerPort(443))
+		} else {
+			attrs = append(attrs, semconv.ServerPort(80))
+		}
+	}
+
+	if req.Attempt > 0 {
+		attrs = append(attrs, semconv.HTTPRequestResendCount(req.Attempt))
+	}
+	return attrs
+}
+
+// fullURL returns uri without user info, and without the query string unless includeQuery is set.
+func fullURL(uri *fasthttp.URI, includeQuery bool) string {
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+	uri.CopyTo(u)
+	u.SetUsername("")
+	u.SetPassword("")
+	if !includeQuery {
+		u.SetQueryString("")
+	}
+	return u.String()
+}
+
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, fasthttp.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "_OTHER"
+}
+
+// headerCarrier adapts fasthttp request headers to propagation.TextMapCarrier.
+type headerCarrier struct {
+	header *fasthttp.RequestHeader
+}
+
+func (c *headerCarrier) Get(key string) string {
+	return string(c.header.Peek(key))
+}
+
+func (c *headerCarrier) Set(key string, value string) {
+	c.header.Set(key, value)
+}
+
+func (c *headerCarrier) Keys() []string {
+	var keys []string
+	c.header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
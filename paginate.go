@@ -0,0 +1,234 @@
+package reqx
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const HeaderLink = "Link"
+
+// NextPage returns the request of the page after page, or nil when page is the last one.
+type NextPage[P any] func(request *Request, resp *Response, page *P) (*Request, error)
+
+type PaginateOption struct {
+	// MaxPages stops after this many pages, 0 is unlimited.
+	MaxPages int
+}
+
+type PaginateOptions func(opts *PaginateOption)
+
+func WithMaxPages(maxPages int) PaginateOptions {
+	return func(opts *PaginateOption) {
+		opts.MaxPages = maxPages
+	}
+}
+
+// Paginate returns an iterator, an iter.Seq2[*P, error], over the pages of request fetched with client.Get
+// and decoded into a new P each. next builds the request of the following page.
+// A failed request, an unsuccessful status code (ErrUnexpectedStatus), an error of next or a done ctx
+// yield an error and end the iteration.
+func Paginate[P any](ctx context.Context, client Client, request *Request, next NextPage[P], opts ...PaginateOptions) func(yield func(*P, error) bool) {
+	opt := &PaginateOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	return func(yield func(*P, error) bool) {
+		req := *request
+		for pages := 0; opt.MaxPages <= 0 || pages < opt.MaxPages; pages++ {
+			if ctx != nil {
+				if err := ctx.Err(); err != nil {
+					yield(nil, err)
+					return
+				}
+				req.Context = ctx
+			}
+
+			page := new(P)
+			req.Result = page
+			resp, err := client.Get(&req)
+			if err == nil && !isSuccess(req.ResultSuccessCheckFunc, resp.StatusCode) {
+				err = fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+
+			nextReq, err := next(&req, resp, page)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if nextReq == nil {
+				return
+			}
+			req = *nextReq
+		}
+	}
+}
+
+// PaginateItems returns an iterator, an iter.Seq2[T, error], over the items of all pages of Paginate.
+func PaginateItems[P any, T any](ctx context.Context, client Client, request *Request, next NextPage[P], items func(page *P) []T, opts ...PaginateOptions) func(yield func(T, error) bool) {
+	return func(yield func(T, error) bool) {
+		Paginate(ctx, client, request, next, opts...)(func(page *P, err error) bool {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return false
+			}
+			for _, item := range items(page) {
+				if !yield(item, nil) {
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+// NextLink follows the RFC 8288 Link header with rel="next", resolved against the URL of the request.
+// Every Link header in resp.HeaderValues is read, so the relations may be sent in separate headers.
+// Responses without HeaderValues, e.g. of mocks, are read from Headers.
+func NextLink[P any]() NextPage[P] {
+	return func(request *Request, resp *Response, page *P) (*Request, error) {
+		header := strings.Join(resp.HeaderValues.Values(HeaderLink), ", ")
+		if header == "" {
+			header = headerValue(resp.Headers, HeaderLink)
+		}
+		link := ParseLinkHeader(header)["next"]
+		if link == "" {
+			return nil, nil
+		}
+		current, err := url.Parse(request.URL)
+		if err != nil {
+			return nil, err
+		}
+		nextURL, err := current.Parse(link)
+		if err != nil {
+			return nil, err
+		}
+		return withURL(request, nextURL.String()), nil
+	}
+}
+
+// NextCursor sets the query parameter param to the cursor of page, e.g. the last id for starting_after,
+// and stops when the cursor is empty.
+func NextCursor[P any](param string, cursor func(page *P) string) NextPage[P] {
+	return func(request *Request, resp *Response, page *P) (*Request, error) {
+		c := cursor(page)
+		if c == "" {
+			return nil, nil
+		}
+		return withQueryParam(request, param, c)
+	}
+}
+
+// NextPageNumber increments the query parameter param, 1 when missing, and stops when count of page is 0.
+func NextPageNumber[P any](param string, count func(page *P) int) NextPage[P] {
+	return func(request *Request, resp *Response, page *P) (*Request, error) {
+		if count(page) == 0 {
+			return nil, nil
+		}
+		number, err := queryParamInt(request.URL, param, 1)
+		if err != nil {
+			return nil, err
+		}
+		return withQueryParam(request, param, strconv.Itoa(number+1))
+	}
+}
+
+// NextOffset adds count of page to the query parameter param, 0 when missing, and stops when count is 0.
+func NextOffset[P any](param string, count func(page *P) int) NextPage[P] {
+	return func(request *Request, resp *Response, page *P) (*Request, error) {
+		n := count(page)
+		if n == 0 {
+			return nil, nil
+		}
+		offset, err := queryParamInt(request.URL, param, 0)
+		if err != nil {
+			return nil, err
+		}
+		return withQueryParam(request, param, strconv.Itoa(offset+n))
+	}
+}
+
+// ParseLinkHeader returns the URLs of an RFC 8288 Link header by relation type.
+func ParseLinkHeader(header string) map[string]string {
+	links := map[string]string{}
+	for header != "" {
+		start := strings.IndexByte(header, '<')
+		end := strings.IndexByte(header, '>')
+		if start < 0 || end < start {
+			break
+		}
+		target := header[start+1 : end]
+		header = header[end+1:]
+
+		params := header
+		if next := strings.IndexByte(header, '<'); next >= 0 {
+			params = header[:next]
+			header = header[next:]
+		} else {
+			header = ""
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(key), "rel") {
+				continue
+			}
+			value = strings.Trim(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), ",")), `"`)
+			for _, rel := range strings.Fields(value) {
+				rel = strings.ToLower(rel)
+				if _, ok := links[rel]; !ok {
+					links[rel] = target
+				}
+			}
+		}
+	}
+	return links
+}
+
+func headerValue(headers Headers, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+func withURL(request *Request, requestURL string) *Request {
+	next := *request
+	next.URL = requestURL
+	return &next
+}
+
+func withQueryParam(request *Request, param string, value string) (*Request, error) {
+	u, err := url.Parse(request.URL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set(param, value)
+	u.RawQuery = query.Encode()
+	return withURL(request, u.String()), nil
+}
+
+func queryParamInt(requestURL string, param string, defaultValue int) (int, error) {
+	u, err := url.Parse(requestURL)
+	if err != nil {
+		return 0, err
+	}
+	value := u.Query().Get(param)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
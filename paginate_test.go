@@ -0,0 +1,200 @@
+package reqx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+type Repo struct {
+	ID int `json:"id"`
+}
+
+type RepoPage struct {
+	Data    []Repo `json:"data"`
+	HasMore bool   `json:"has_more"`
+}
+
+func paginateHandler(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	ctx.SetContentType(reqx.HeaderContentTypeJson)
+	switch string(ctx.Path()) {
+	case "/repos":
+		// GitHub style, 3 pages of 2 repos with absolute links.
+		page, _ := strconv.Atoi(string(args.Peek("page")))
+		if page == 0 {
+			page = 1
+		}
+		if page < 3 {
+			ctx.Response.Header.Set(reqx.HeaderLink, fmt.Sprintf(`<http://api.local/repos?page=%d>; rel="next", <http://api.local/repos?page=3>; rel="last"`, page+1))
+		}
+		ctx.SetBodyString(fmt.Sprintf(`{"data":[{"id":%d},{"id":%d}]}`, page*2-1, page*2))
+	case "/tags":
+		// One Link header per relation.
+		page, _ := strconv.Atoi(string(args.Peek("page")))
+		if page == 0 {
+			page = 1
+		}
+		if page < 2 {
+			ctx.Response.Header.Add(reqx.HeaderLink, fmt.Sprintf(`</tags?page=%d>; rel="next"`, page+1))
+		}
+		ctx.Response.Header.Add(reqx.HeaderLink, `</tags?page=2>; rel="last"`)
+		ctx.SetBodyString(fmt.Sprintf(`{"data":[{"id":%d}]}`, page))
+	case "/charges":
+		// Stripe style, starting_after the last id while has_more.
+		after, _ := strconv.Atoi(string(args.Peek("starting_after")))
+		hasMore := after+2 < 5
+		ctx.SetBodyString(fmt.Sprintf(`{"data":[{"id":%d},{"id":%d}],"has_more":%t}`, after+1, after+2, hasMore))
+	case "/items":
+		offset, _ := strconv.Atoi(string(args.Peek("offset")))
+		if offset >= 4 {
+			ctx.SetBodyString(`{"data":[]}`)
+			return
+		}
+		ctx.SetBodyString(fmt.Sprintf(`{"data":[{"id":%d},{"id":%d}]}`, offset+1, offset+2))
+	default:
+		ctx.SetStatusCode(http.StatusNotFound)
+		ctx.SetBodyString(`{"message":"not found"}`)
+	}
+}
+
+func repos(page *RepoPage) []Repo {
+	return page.Data
+}
+
+func collect(t *testing.T, seq func(yield func(Repo, error) bool)) ([]int, error) {
+	t.Helper()
+	var ids []int
+	var err error
+	seq(func(repo Repo, e error) bool {
+		if e != nil {
+			err = e
+			return false
+		}
+		ids = append(ids, repo.ID)
+		return true
+	})
+	return ids, err
+}
+
+func Test_PaginateLink(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(paginateHandler), reqx.WithBaseURL("http://api.local"))
+
+	ids, err := collect(t, reqx.PaginateItems(context.Background(), client, &reqx.Request{URL: "/repos"}, reqx.NextLink[RepoPage](), repos))
+	if err != nil || fmt.Sprint(ids) != "[1 2 3 4 5 6]" {
+		t.Errorf("Test_PaginateLink Error: %v, %v", ids, err)
+	}
+
+	ids, err = collect(t, reqx.PaginateItems(context.Background(), client, &reqx.Request{URL: "/repos"}, reqx.NextLink[RepoPage](), repos, reqx.WithMaxPages(2)))
+	if err != nil || fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Errorf("Test_PaginateLink Error: max pages %v, %v", ids, err)
+	}
+}
+
+func Test_PaginateLink_MultipleHeaders(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(paginateHandler))
+
+	ids, err := collect(t, reqx.PaginateItems(context.Background(), client, &reqx.Request{URL: "http://api.local/tags"}, reqx.NextLink[RepoPage](), repos))
+	if err != nil || fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Test_PaginateLink_MultipleHeaders Error: %v, %v", ids, err)
+	}
+}
+
+// mockPages serves two pages with their Link headers in HeaderValues, as a mock of a Client would.
+type mockPages struct {
+	reqx.Client
+}
+
+func (m mockPages) Get(request *reqx.Request) (*reqx.Response, error) {
+	page := request.Result.(*RepoPage)
+	if request.URL == "http://api.local/tags?page=2" {
+		page.Data = []Repo{{ID: 2}}
+		return &reqx.Response{StatusCode: http.StatusOK}, nil
+	}
+	page.Data = []Repo{{ID: 1}}
+	return &reqx.Response{
+		StatusCode: http.StatusOK,
+		HeaderValues: http.Header{
+			reqx.HeaderLink: {`</tags?page=2>; rel="next"`, `</tags?page=2>; rel="last"`},
+		},
+	}, nil
+}
+
+func Test_PaginateLink_Mock(t *testing.T) {
+	ids, err := collect(t, reqx.PaginateItems(context.Background(), mockPages{}, &reqx.Request{URL: "http://api.local/tags"}, reqx.NextLink[RepoPage](), repos))
+	if err != nil || fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Test_PaginateLink_Mock Error: %v, %v", ids, err)
+	}
+}
+
+func Test_PaginateCursor(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(paginateHandler))
+
+	next := reqx.NextCursor("starting_after", func(page *RepoPage) string {
+		if !page.HasMore || len(page.Data) == 0 {
+			return ""
+		}
+		return strconv.Itoa(page.Data[len(page.Data)-1].ID)
+	})
+	pages := 0
+	var ids []int
+	reqx.Paginate(context.Background(), client, &reqx.Request{URL: "http://api.local/charges?limit=2"}, next)(func(page *RepoPage, err error) bool {
+		if err != nil {
+			t.Errorf("Test_PaginateCursor Error: %v", err)
+			return false
+		}
+		pages++
+		for _, repo := range page.Data {
+			ids = append(ids, repo.ID)
+		}
+		return true
+	})
+	if pages != 3 || fmt.Sprint(ids) != "[1 2 3 4 5 6]" {
+		t.Errorf("Test_PaginateCursor Error: pages %d, %v", pages, ids)
+	}
+}
+
+func Test_PaginateOffset(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(paginateHandler))
+
+	next := reqx.NextOffset("offset", func(page *RepoPage) int {
+		return len(page.Data)
+	})
+	ids, err := collect(t, reqx.PaginateItems(context.Background(), client, &reqx.Request{URL: "http://api.local/items"}, next, repos))
+	if err != nil || fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Errorf("Test_PaginateOffset Error: %v, %v", ids, err)
+	}
+}
+
+func Test_PaginateError(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(paginateHandler))
+
+	_, err := collect(t, reqx.PaginateItems(context.Background(), client, &reqx.Request{URL: "http://api.local/missing"}, reqx.NextLink[RepoPage](), repos))
+	if !errors.Is(err, reqx.ErrUnexpectedStatus) {
+		t.Errorf("Test_PaginateError Error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ids, err := collect(t, reqx.PaginateItems(ctx, client, &reqx.Request{URL: "http://api.local/repos"}, reqx.NextLink[RepoPage](), func(page *RepoPage) []Repo {
+		cancel()
+		return page.Data
+	}))
+	if !errors.Is(err, context.Canceled) || fmt.Sprint(ids) != "[1 2]" {
+		t.Errorf("Test_PaginateError Error: canceled %v, %v", ids, err)
+	}
+}
+
+func Test_ParseLinkHeader(t *testing.T) {
+	links := reqx.ParseLinkHeader(`<https://api.local/items?page=2>; rel="next last", <https://api.local/items?page=1>; rel=prev, <https://api.local/items?a=1,2>; title="a, b"; REL="alternate"`)
+	if links["next"] != "https://api.local/items?page=2" || links["last"] != "https://api.local/items?page=2" ||
+		links["prev"] != "https://api.local/items?page=1" || links["alternate"] != "https://api.local/items?a=1,2" {
+		t.Errorf("Test_ParseLinkHeader Error: %v", links)
+	}
+}
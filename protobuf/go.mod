@@ -0,0 +1,21 @@
+module github.com/dreamph/reqx/protobuf
+
+go 1.22.0
+
+require (
+	github.com/dreamph/reqx v0.0.0-00010101000000-000000000000
+	github.com/goccy/go-json v0.10.4
+	github.com/valyala/fasthttp v1.58.0
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/fasthttp/websocket v1.5.12 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/savsgio/gotils v0.0.0-20240704082632-aef3928b8a38 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	golang.org/x/net v0.33.0 // indirect
+)
+
+replace github.com/dreamph/reqx => ../
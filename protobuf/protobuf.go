@@ -0,0 +1,135 @@
+package protobuf
+
+import (
+	"errors"
+	"fmt"
+
+	gojson "github.com/goccy/go-json"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/dreamph/reqx"
+)
+
+const (
+	ContentType            = "application/x-protobuf"
+	ContentTypeApplication = "application/protobuf"
+)
+
+var ErrNotProtoMessage = errors.New("reqx/protobuf: value is not a proto.Message")
+
+type Options struct {
+	// ProtoJSON decodes and encodes application/json bodies of proto.Message values with protojson,
+	// so field names follow proto conventions. Other values use the JSON codec, JsonMarshal and
+	// JsonUnmarshal of the client.
+	ProtoJSON bool
+	// MarshalOptions and UnmarshalOptions configure protojson.
+	MarshalOptions   protojson.MarshalOptions
+	UnmarshalOptions protojson.UnmarshalOptions
+}
+
+// WithProtobuf registers the protobuf codec for application/x-protobuf and application/protobuf,
+// and the protojson codec for application/json when opts.ProtoJSON is set.
+func WithProtobuf(opts Options) reqx.ClientOptions {
+	return func(opt *reqx.ClientOption) {
+		reqx.WithCodec(NewCodec(), ContentType, ContentTypeApplication)(opt)
+		if opts.ProtoJSON {
+			c := NewJSONCodec(opts.MarshalOptions, opts.UnmarshalOptions).(*jsonCodec)
+			c.json = clientJSONCodec(opt)
+			reqx.WithCodec(c)(opt)
+		}
+	}
+}
+
+// clientJSONCodec returns the JSON codec registered on opt before protojson, or one that uses
+// opt.JsonMarshal and opt.JsonUnmarshal as they are when the client is created.
+func clientJSONCodec(opt *reqx.ClientOption) reqx.Codec {
+	if codec, ok := opt.Codecs[reqx.HeaderContentTypeJson]; ok {
+		return codec
+	}
+	return reqx.NewJsonCodec(
+		func(v interface{}) ([]byte, error) {
+			if opt.JsonMarshal != nil {
+				return opt.JsonMarshal(v)
+			}
+			return gojson.Marshal(v)
+		},
+		func(data []byte, v interface{}) error {
+			if opt.JsonUnmarshal != nil {
+				return opt.JsonUnmarshal(data, v)
+			}
+			return gojson.Unmarshal(data, v)
+		},
+	)
+}
+
+// Body is a request body of m sent as application/x-protobuf.
+func Body(m proto.Message) *reqx.Body {
+	return &reqx.Body{
+		ContentType: ContentType,
+		Data:        m,
+	}
+}
+
+type codec struct{}
+
+// NewCodec returns a Codec for proto.Message values.
+func NewCodec() reqx.Codec {
+	return codec{}
+}
+
+func (codec) ContentType() string {
+	return ContentType
+}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	return proto.Marshal(m)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T", ErrNotProtoMessage, v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+type jsonCodec struct {
+	marshalOptions   protojson.MarshalOptions
+	unmarshalOptions protojson.UnmarshalOptions
+	json             reqx.Codec
+}
+
+// NewJSONCodec returns a Codec for application/json that uses protojson for proto.Message values
+// and goccy/go-json for other values.
+func NewJSONCodec(marshalOptions protojson.MarshalOptions, unmarshalOptions protojson.UnmarshalOptions) reqx.Codec {
+	return &jsonCodec{
+		marshalOptions:   marshalOptions,
+		unmarshalOptions: unmarshalOptions,
+		json:             reqx.NewJsonCodec(gojson.Marshal, gojson.Unmarshal),
+	}
+}
+
+func (c *jsonCodec) ContentType() string {
+	return reqx.HeaderContentTypeJson
+}
+
+func (c *jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return c.json.Marshal(v)
+	}
+	return c.marshalOptions.Marshal(m)
+}
+
+func (c *jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return c.json.Unmarshal(data, v)
+	}
+	return c.unmarshalOptions.Unmarshal(data, m)
+}
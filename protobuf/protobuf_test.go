@@ -0,0 +1,139 @@
+package protobuf_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/protobuf"
+)
+
+func Test_Protobuf(t *testing.T) {
+	client := reqx.New(
+		protobuf.WithProtobuf(protobuf.Options{}),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Request.Header.ContentType()) != protobuf.ContentType {
+				t.Errorf("Test_Protobuf Error: content type %s", ctx.Request.Header.ContentType())
+			}
+			var name wrapperspb.StringValue
+			err := proto.Unmarshal(ctx.Request.Body(), &name)
+			if err != nil {
+				t.Error(err)
+			}
+
+			var reply proto.Message = wrapperspb.String("hello " + name.GetValue())
+			if name.GetValue() == "" {
+				ctx.SetStatusCode(http.StatusBadRequest)
+				reply, _ = structpb.NewStruct(map[string]interface{}{"error": "name is required"})
+			}
+			body, err := proto.Marshal(reply)
+			if err != nil {
+				t.Error(err)
+			}
+			ctx.SetContentType(protobuf.ContentType)
+			ctx.SetBody(body)
+		}),
+	)
+
+	var result wrapperspb.StringValue
+	var errorResult structpb.Struct
+	_, err := client.Post(&reqx.Request{
+		URL:         "http://api.local/greet",
+		Data:        protobuf.Body(wrapperspb.String("reqx")),
+		Result:      &result,
+		ErrorResult: &errorResult,
+	})
+	if err != nil || result.GetValue() != "hello reqx" {
+		t.Errorf("Test_Protobuf Error: %v, %v", result.GetValue(), err)
+	}
+
+	resp, err := client.Post(&reqx.Request{
+		URL:         "http://api.local/greet",
+		Data:        protobuf.Body(wrapperspb.String("")),
+		Result:      &result,
+		ErrorResult: &errorResult,
+	})
+	if err != nil || resp.StatusCode != http.StatusBadRequest || errorResult.GetFields()["error"].GetStringValue() != "name is required" {
+		t.Errorf("Test_Protobuf Error: %d %v, %v", resp.StatusCode, errorResult.GetFields(), err)
+	}
+
+	_, err = client.Post(&reqx.Request{
+		URL:  "http://api.local/greet",
+		Data: &reqx.Body{ContentType: protobuf.ContentType, Data: map[string]string{}},
+	})
+	if !errors.Is(err, protobuf.ErrNotProtoMessage) {
+		t.Errorf("Test_Protobuf Error: %v", err)
+	}
+}
+
+func Test_ProtoJSON(t *testing.T) {
+	var body string
+	client := reqx.New(
+		protobuf.WithProtobuf(protobuf.Options{ProtoJSON: true}),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			body = string(ctx.Request.Body())
+			ctx.SetContentType(reqx.HeaderContentTypeJson)
+			ctx.SetBodyString(`"2024-01-02T03:04:05Z"`)
+		}),
+	)
+
+	var result timestamppb.Timestamp
+	_, err := client.Post(&reqx.Request{
+		URL:    "http://api.local/time",
+		Data:   timestamppb.New(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		Result: &result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != `"2024-01-01T00:00:00Z"` || !result.AsTime().Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Test_ProtoJSON Error: %s %v", body, result.AsTime())
+	}
+
+	var plain map[string]string
+	_, err = client.Post(&reqx.Request{
+		URL:    "http://api.local/time",
+		Data:   map[string]string{"name": "reqx"},
+		Result: &plain,
+	})
+	if err == nil || body != `{"name":"reqx"}` {
+		t.Errorf("Test_ProtoJSON Error: %s %v", body, err)
+	}
+}
+
+func Test_ProtoJSON_JsonMarshal(t *testing.T) {
+	var body string
+	client := reqx.New(
+		reqx.WithJsonMarshal(func(v interface{}) ([]byte, error) {
+			return []byte(`{"custom":true}`), nil
+		}),
+		reqx.WithJsonUnmarshal(func(data []byte, v interface{}) error {
+			(*v.(*map[string]string))["name"] = "custom"
+			return nil
+		}),
+		protobuf.WithProtobuf(protobuf.Options{ProtoJSON: true}),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			body = string(ctx.Request.Body())
+			ctx.SetContentType(reqx.HeaderContentTypeJson)
+			ctx.SetBodyString(`{"name":"reqx"}`)
+		}),
+	)
+
+	result := map[string]string{}
+	_, err := client.Post(&reqx.Request{
+		URL:    "http://api.local/users",
+		Data:   map[string]string{"name": "reqx"},
+		Result: &result,
+	})
+	if err != nil || body != `{"custom":true}` || result["name"] != "custom" {
+		t.Errorf("Test_ProtoJSON_JsonMarshal Error: %s %s %v", body, result, err)
+	}
+}
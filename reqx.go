@@ -3,7 +3,10 @@ package reqx
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -12,14 +15,15 @@ import (
 	"sync"
 	"time"
 
-	gojson "github.com/goccy/go-json"
 	//"github.com/goccy/go-reflect"
 	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
 )
 
 const (
-	HeaderAuthorization = "Authorization"
-	HeaderContentType   = "Content-Type"
+	HeaderAuthorization   = "Authorization"
+	HeaderContentType     = "Content-Type"
+	HeaderWWWAuthenticate = "WWW-Authenticate"
 )
 
 var (
@@ -32,6 +36,7 @@ var (
 
 const (
 	defaultUserAgent = "reqx-http-client"
+	maxAuthRetries   = 1
 )
 
 type Request struct {
@@ -43,12 +48,17 @@ type Request struct {
 	ErrorResult            interface{}
 	Timeout                time.Duration
 	ResultSuccessCheckFunc func(statusCode int) bool
+	// Compression overrides the client compression, an empty Encoding disables it.
+	Compression *Compression
 }
 
 type Response struct {
 	StatusCode int
 	Headers    Headers
 	TotalTime  time.Duration
+	// HeaderValues has every value of the response headers by canonical name,
+	// e.g. of Link or Set-Cookie headers sent more than once. Headers keeps one value per name.
+	HeaderValues http.Header
 }
 
 type FileParam struct {
@@ -71,22 +81,41 @@ type Raw struct {
 }
 
 type ClientOption struct {
-	Timeout            time.Duration
-	BaseURL            string
-	UserAgent          string
-	TlsConfig          *tls.Config
-	MaxConnsPerHost    int
-	Headers            Headers
-	MaxRedirectsCount  int
-	OnBeforeRequest    OnBeforeRequest
-	OnRequestCompleted OnRequestCompleted
-	OnRequestError     OnRequestError
-	JsonMarshal        func(v interface{}) ([]byte, error)
-	JsonUnmarshal      func(data []byte, v interface{}) error
+	Timeout              time.Duration
+	BaseURL              string
+	UserAgent            string
+	TlsConfig            *tls.Config
+	MaxConnsPerHost      int
+	Headers              Headers
+	MaxRedirectsCount    int
+	OnBeforeRequest      OnBeforeRequest
+	OnRequestCompleted   OnRequestCompleted
+	OnRequestError       OnRequestError
+	BeforeSign           []OnBeforeRequest
+	AfterAttempt         []OnRequestCompleted
+	Authenticator        Authenticator
+	Signer               Signer
+	Transport            Transport
+	Handler              fasthttp.RequestHandler
+	Middlewares          []TransportMiddleware
+	Debug                bool
+	Logger               *slog.Logger
+	LogOptions           []LogOptions
+	Compression          *Compression
+	DisableDecompression bool
+	MaxDecompressedSize  int
+	// Codecs by content type, in addition to the default JSON codec.
+	Codecs map[string]Codec
+	// Deprecated: use Codecs with NewJsonCodec.
+	JsonMarshal func(v interface{}) ([]byte, error)
+	// Deprecated: use Codecs with NewJsonCodec.
+	JsonUnmarshal func(data []byte, v interface{}) error
 }
 
 type ClientOptions func(opts *ClientOption)
 
+// WithBaseURL prefixes the URL of every request with baseURL, unless the request URL is absolute,
+// i.e. has a scheme such as https://. Absolute URLs, e.g. the next links of Paginate, are sent as they are.
 func WithBaseURL(baseURL string) ClientOptions {
 	return func(opts *ClientOption) {
 		opts.BaseURL = baseURL
@@ -141,12 +170,79 @@ func WithOnRequestError(onRequestError OnRequestError) ClientOptions {
 	}
 }
 
+// WithBeforeSign adds hooks that run on every attempt after OnBeforeRequest and right before the Signer,
+// e.g. to set headers that the signature must cover.
+func WithBeforeSign(hooks ...OnBeforeRequest) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.BeforeSign = append(opts.BeforeSign, hooks...)
+	}
+}
+
+// WithAfterAttempt adds hooks that run after every attempt, also when preparing or sending it failed,
+// e.g. to end what a BeforeSign hook started. The body of a streamed response is not read yet.
+func WithAfterAttempt(hooks ...OnRequestCompleted) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.AfterAttempt = append(opts.AfterAttempt, hooks...)
+	}
+}
+
+func WithAuthenticator(authenticator Authenticator) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Authenticator = authenticator
+	}
+}
+
+func WithSigner(signer Signer) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Signer = signer
+	}
+}
+
+func WithTransport(transport Transport) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Transport = transport
+	}
+}
+
+// WithTransportMiddleware wraps the transport with middlewares. The first middleware is the outermost.
+func WithTransportMiddleware(middlewares ...TransportMiddleware) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Middlewares = append(opts.Middlewares, middlewares...)
+	}
+}
+
+// WithHandler sends requests to handler through an in-memory listener instead of the network.
+// The listener is served until the client is closed with Close.
+func WithHandler(handler fasthttp.RequestHandler) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Handler = handler
+	}
+}
+
+// WithHTTPHandler sends requests to a net/http handler through an in-memory listener instead of the network.
+// The listener is served until the client is closed with Close.
+func WithHTTPHandler(handler http.Handler) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Handler = fasthttpadaptor.NewFastHTTPHandler(handler)
+	}
+}
+
+// WithDebug logs the curl command of failed requests, with secrets masked, before OnRequestError is called.
+// It logs with the logger of WithLogger when set, and with the log package otherwise.
+func WithDebug(debug bool) ClientOptions {
+	return func(opts *ClientOption) {
+		opts.Debug = debug
+	}
+}
+
+// Deprecated: use WithCodec with NewJsonCodec.
 func WithJsonMarshal(jsonMarshal func(v interface{}) ([]byte, error)) ClientOptions {
 	return func(opts *ClientOption) {
 		opts.JsonMarshal = jsonMarshal
 	}
 }
 
+// Deprecated: use WithCodec with NewJsonCodec.
 func WithJsonUnmarshal(jsonUnmarshal func(data []byte, v interface{}) error) ClientOptions {
 	return func(opts *ClientOption) {
 		opts.JsonUnmarshal = jsonUnmarshal
@@ -178,6 +274,17 @@ type Headers map[string]string
 type RequestInfo struct {
 	*fasthttp.Request
 	Context context.Context
+	// Attempt counts how often the request was sent before, e.g. when answering an authentication challenge.
+	Attempt int
+
+	resultSuccessCheck func(statusCode int) bool
+	stream             *streamConn
+	webSocket          *webSocketHandshake
+}
+
+// IsSuccess reports whether statusCode is a success for this request, using Request.ResultSuccessCheckFunc when set.
+func (r *RequestInfo) IsSuccess(statusCode int) bool {
+	return isSuccess(r.resultSuccessCheck, statusCode)
 }
 
 type ResponseInfo struct {
@@ -202,25 +309,29 @@ type Client interface {
 }
 
 type httpClient struct {
-	client             *fasthttp.Client
-	baseURL            string
-	userAgent          string
-	timeout            time.Duration
-	headers            Headers
-	maxRedirectsCount  int
-	onBeforeRequest    OnBeforeRequest
-	onRequestCompleted OnRequestCompleted
-	onRequestError     OnRequestError
-	jsonMarshal        func(v interface{}) ([]byte, error)
-	jsonUnmarshal      func(data []byte, v interface{}) error
+	transport            Transport
+	baseURL              string
+	userAgent            string
+	timeout              time.Duration
+	headers              Headers
+	onBeforeRequest      OnBeforeRequest
+	onRequestCompleted   OnRequestCompleted
+	onRequestError       OnRequestError
+	beforeSign           []OnBeforeRequest
+	afterAttempt         []OnRequestCompleted
+	authenticator        Authenticator
+	signer               Signer
+	compression          *Compression
+	disableDecompression bool
+	maxDecompressedSize  int
+	codecs               *codecs
+	closer               io.Closer
 }
 
 func defaultClientOption() *ClientOption {
 	return &ClientOption{
-		Timeout:       time.Second * 30,
-		UserAgent:     defaultUserAgent,
-		JsonMarshal:   gojson.Marshal,
-		JsonUnmarshal: gojson.Unmarshal,
+		Timeout:   time.Second * 30,
+		UserAgent: defaultUserAgent,
 	}
 }
 
@@ -240,41 +351,69 @@ func NewClient(opt *ClientOption) Client {
 }
 
 func newClient(opt *ClientOption) Client {
-	tcpDialer := fasthttp.TCPDialer{
-		Concurrency:      4096,
-		DNSCacheDuration: time.Hour,
-	}
-	maxIdleConnDuration := time.Hour * 1
-	fastHttpClient := &fasthttp.Client{
-		Name:                          opt.UserAgent,
-		ReadTimeout:                   opt.Timeout,
-		WriteTimeout:                  opt.Timeout,
-		MaxIdleConnDuration:           maxIdleConnDuration,
-		NoDefaultUserAgentHeader:      true, // Don't send: User-Agent: fasthttp
-		DisableHeaderNamesNormalizing: true, // If you set the case on your headers correctly you can enable this
-		DisablePathNormalizing:        true,
-		Dial:                          tcpDialer.Dial,
-		MaxConnsPerHost:               opt.MaxConnsPerHost,
-		TLSConfig:                     opt.TlsConfig,
+	var closer io.Closer
+	transport := opt.Transport
+	if transport == nil {
+		fastHttpTransport := newFastHttpTransport(opt)
+		transport, closer = fastHttpTransport, fastHttpTransport
+	}
+	transport = newWebSocketTransport(opt, transport)
+	if opt.Logger != nil {
+		transport = newLogTransport(transport, opt.Logger, opt.LogOptions)
+	}
+	for i := len(opt.Middlewares) - 1; i >= 0; i-- {
+		transport = opt.Middlewares[i](transport)
+	}
+
+	onRequestError := opt.OnRequestError
+	if opt.Debug {
+		onRequestError = debugOnRequestError(onRequestError, opt.Logger)
 	}
 
 	c := &httpClient{
-		client:             fastHttpClient,
-		baseURL:            opt.BaseURL,
-		userAgent:          opt.UserAgent,
-		timeout:            opt.Timeout,
-		headers:            opt.Headers,
-		maxRedirectsCount:  opt.MaxRedirectsCount,
-		jsonMarshal:        opt.JsonMarshal,
-		jsonUnmarshal:      opt.JsonUnmarshal,
-		onBeforeRequest:    opt.OnBeforeRequest,
-		onRequestCompleted: opt.OnRequestCompleted,
-		onRequestError:     opt.OnRequestError,
+		transport:            transport,
+		baseURL:              opt.BaseURL,
+		userAgent:            opt.UserAgent,
+		timeout:              opt.Timeout,
+		headers:              opt.Headers,
+		codecs:               newCodecs(opt),
+		closer:               closer,
+		onBeforeRequest:      opt.OnBeforeRequest,
+		onRequestCompleted:   opt.OnRequestCompleted,
+		onRequestError:       onRequestError,
+		beforeSign:           opt.BeforeSign,
+		afterAttempt:         opt.AfterAttempt,
+		authenticator:        opt.Authenticator,
+		signer:               opt.Signer,
+		compression:          opt.Compression,
+		disableDecompression: opt.DisableDecompression,
+		maxDecompressedSize:  opt.MaxDecompressedSize,
 	}
 
 	return c
 }
 
+// ErrUnsupportedClient is returned by Curl, Stream, SSE and WebSocket for a client without the method they call,
+// e.g. a wrapper of a Client that does not forward it.
+var ErrUnsupportedClient = errors.New("reqx: client does not support the request")
+
+// Close closes the idle connections of client and stops the server of WithHandler or WithHTTPHandler.
+// It does nothing for clients with a custom Transport.
+func Close(client Client) error {
+	closer, ok := client.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
+
+func (c *httpClient) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	return c.closer.Close()
+}
+
 func (c *httpClient) Get(request *Request) (*Response, error) {
 	return c.do(request, fasthttp.MethodGet)
 }
@@ -323,94 +462,139 @@ func (c *httpClient) do(request *Request, method string) (*Response, error) {
 		return nil, err
 	}
 
-	if c.onBeforeRequest != nil {
-		c.onBeforeRequest(&RequestInfo{
-			Request: req,
-			Context: ctx,
-		})
-	}
-
-	if c.maxRedirectsCount > 0 {
-		err = c.client.DoRedirects(req, resp, c.maxRedirectsCount)
-	} else {
-		err = c.client.Do(req, resp)
+	reqInfo := &RequestInfo{
+		Request:            req,
+		Context:            ctx,
+		resultSuccessCheck: request.ResultSuccessCheckFunc,
 	}
 
+	err = c.attempt(reqInfo, resp, start, c.doRequest, resp.Reset)
 	totalTime := time.Since(start)
 	if err != nil {
-		if c.onRequestCompleted != nil {
-			c.onRequestCompleted(
-				&RequestInfo{
-					Request: req,
-					Context: ctx,
-				},
-				&ResponseInfo{
-					Response:  resp,
-					TotalTime: totalTime,
-					Err:       err,
-				},
-			)
-		}
-
-		if c.onRequestError != nil {
-			c.onRequestError(
-				&RequestInfo{
-					Request: req,
-					Context: ctx,
-				},
-				&ResponseInfo{
-					Response:  resp,
-					TotalTime: totalTime,
-					Err:       err,
-				},
-			)
-		}
-
+		c.completed(reqInfo, resp, totalTime, err)
 		return &Response{
-			StatusCode: resp.StatusCode(),
-			TotalTime:  time.Since(start),
-			Headers:    getResponseHeaders(resp),
+			StatusCode:   resp.StatusCode(),
+			TotalTime:    totalTime,
+			Headers:      getResponseHeaders(resp),
+			HeaderValues: getResponseHeaderValues(resp),
 		}, err
 	}
 
-	err = c.initResponse(request, req, resp)
+	err = c.decompressResponse(resp)
+	if err == nil {
+		err = c.initResponse(request, req, resp)
+	}
+	c.completed(reqInfo, resp, totalTime, err)
 	if err != nil {
 		return nil, err
 	}
 
+	return &Response{
+		StatusCode:   resp.StatusCode(),
+		TotalTime:    totalTime,
+		Headers:      getResponseHeaders(resp),
+		HeaderValues: getResponseHeaderValues(resp),
+	}, nil
+}
+
+// attempt sends req with send until no authentication challenge asks to send it again.
+// Every attempt is prepared by prepareAttempt and followed by the AfterAttempt hooks, reset clears resp before the next one.
+// When answering a challenge fails, resp keeps the response that asked for authentication.
+func (c *httpClient) attempt(req *RequestInfo, resp *fasthttp.Response, start time.Time, send func(req *RequestInfo, resp *fasthttp.Response) error, reset func()) error {
+	for attempt := 0; ; attempt++ {
+		req.Attempt = attempt
+		err := c.prepareAttempt(req)
+		if err == nil {
+			err = send(req, resp)
+		}
+		for _, hook := range c.afterAttempt {
+			hook(req, &ResponseInfo{
+				Response:  resp,
+				Context:   req.Context,
+				TotalTime: time.Since(start),
+				Err:       err,
+			})
+		}
+		if err != nil || attempt >= maxAuthRetries {
+			return err
+		}
+
+		retry, err := c.challenge(req, resp, time.Since(start))
+		if err != nil {
+			return fmt.Errorf("reqx: authentication challenge of status %d failed: %w", resp.StatusCode(), err)
+		}
+		if !retry {
+			return nil
+		}
+		reset()
+	}
+}
+
+// completed calls OnRequestCompleted, and OnRequestError when err is set or the status code of resp is no success.
+func (c *httpClient) completed(req *RequestInfo, resp *fasthttp.Response, totalTime time.Duration, err error) {
 	if c.onRequestCompleted != nil {
 		c.onRequestCompleted(
-			&RequestInfo{
-				Request: req,
-				Context: ctx,
+			req,
+			&ResponseInfo{
+				Response:  resp,
+				TotalTime: totalTime,
+				Err:       err,
 			},
+		)
+	}
+
+	if (err != nil || !req.IsSuccess(resp.StatusCode())) && c.onRequestError != nil {
+		c.onRequestError(
+			req,
 			&ResponseInfo{
 				Response:  resp,
 				TotalTime: totalTime,
+				Err:       err,
 			},
 		)
 	}
+}
 
-	if c.isUnResultSuccess(request, resp.StatusCode()) {
-		if c.onRequestError != nil {
-			c.onRequestError(
-				&RequestInfo{
-					Request: req,
-					Context: ctx,
-				},
-				&ResponseInfo{
-					Response:  resp,
-					TotalTime: totalTime,
-				},
-			)
+// prepareAttempt authenticates, calls OnBeforeRequest and the BeforeSign hooks and signs req before every attempt.
+func (c *httpClient) prepareAttempt(req *RequestInfo) error {
+	if c.authenticator != nil && !skipAuthentication(req.Context) {
+		err := c.authenticator.Authenticate(req)
+		if err != nil {
+			return err
 		}
 	}
 
-	return &Response{
-		StatusCode: resp.StatusCode(),
-		TotalTime:  totalTime,
-		Headers:    getResponseHeaders(resp),
-	}, nil
+	if c.onBeforeRequest != nil {
+		c.onBeforeRequest(req)
+	}
+
+	for _, hook := range c.beforeSign {
+		hook(req)
+	}
+
+	if c.signer != nil {
+		err := c.signer.Sign(req)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *httpClient) doRequest(req *RequestInfo, resp *fasthttp.Response) error {
+	return c.transport.Do(req, resp)
+}
+
+func (c *httpClient) challenge(req *RequestInfo, resp *fasthttp.Response, totalTime time.Duration) (bool, error) {
+	authenticator, ok := c.authenticator.(ChallengeAuthenticator)
+	if !ok || skipAuthentication(req.Context) {
+		return false, nil
+	}
+	return authenticator.Challenge(req, &ResponseInfo{
+		Response:  resp,
+		Context:   req.Context,
+		TotalTime: totalTime,
+	})
 }
 
 func getResponseHeaders(resp *fasthttp.Response) Headers {
@@ -422,11 +606,24 @@ func getResponseHeaders(resp *fasthttp.Response) Headers {
 	return headersMap
 }
 
+func getResponseHeaderValues(resp *fasthttp.Response) http.Header {
+	header := http.Header{}
+	resp.Header.VisitAll(func(key, value []byte) {
+		header.Add(string(key), string(value))
+	})
+	return header
+}
+
+// getRequestURL prefixes requestURL with the base URL unless it is absolute, e.g. a URL of a Link header.
+// getRequestURL prefixes requestURL with the base URL unless requestURL is absolute.
 func (c *httpClient) getRequestURL(requestURL string) string {
-	if c.baseURL != "" {
-		return c.baseURL + requestURL
+	if c.baseURL == "" {
+		return requestURL
 	}
-	return requestURL
+	if u, err := url.Parse(requestURL); err == nil && u.IsAbs() {
+		return requestURL
+	}
+	return c.baseURL + requestURL
 }
 
 func (c *httpClient) initRequest(req *fasthttp.Request, resp *fasthttp.Response, request *Request, method string) error {
@@ -447,6 +644,8 @@ func (c *httpClient) initRequest(req *fasthttp.Request, resp *fasthttp.Response,
 		}
 	}
 
+	c.setAcceptEncoding(req)
+
 	req.SetTimeout(c.timeout)
 	if request.Timeout > 0 {
 		req.SetTimeout(request.Timeout)
@@ -470,7 +669,7 @@ func (c *httpClient) initContentTypeAndBodyRequest(req *fasthttp.Request, _ *fas
 			req.Header.SetContentType(contentType)
 		}
 		req.SetBody(rawBody.Body)
-		return nil
+		return c.compressBody(req, request)
 	}
 
 	form, ok := c.getFormBody(request.Data)
@@ -518,14 +717,32 @@ func (c *httpClient) initContentTypeAndBodyRequest(req *fasthttp.Request, _ *fas
 		return nil
 	}
 
-	req.Header.SetContentTypeBytes(HeaderContentTypeJsonBytes)
-	dataBytes, err := c.jsonMarshal(request.Data)
+	data := request.Data
+	body, ok := c.getBody(request.Data)
+	if ok {
+		data = body.Data
+		if contentType == "" {
+			contentType = body.ContentType
+		}
+	}
+
+	// Data with an unregistered Content-Type header is sent as JSON, a Body requires a registered codec.
+	codec, err := c.codecs.request(contentType, ok)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		req.Header.SetContentType(codec.ContentType())
+	} else {
+		req.Header.SetContentType(contentType)
+	}
+	dataBytes, err := codec.Marshal(data)
 	if err != nil {
 		return err
 	}
 	req.SetBodyRaw(dataBytes)
 
-	return nil
+	return c.compressBody(req, request)
 }
 
 func (c *httpClient) initResponse(request *Request, _ *fasthttp.Request, resp *fasthttp.Response) error {
@@ -550,18 +767,18 @@ func (c *httpClient) initResponse(request *Request, _ *fasthttp.Request, resp *f
 }
 
 func (c *httpClient) isResultSuccess(request *Request, statusCode int) bool {
-	if request.ResultSuccessCheckFunc != nil {
-		return request.ResultSuccessCheckFunc(statusCode)
-	} else {
-		return c.isDefaultResultSuccess(statusCode)
-	}
+	return isSuccess(request.ResultSuccessCheckFunc, statusCode)
 }
 
 func (c *httpClient) isUnResultSuccess(request *Request, statusCode int) bool {
 	return !c.isResultSuccess(request, statusCode)
 }
 
-func (c *httpClient) isDefaultResultSuccess(statusCode int) bool {
+// isSuccess reports whether statusCode is a success, using check when set and 2xx otherwise.
+func isSuccess(check func(statusCode int) bool, statusCode int) bool {
+	if check != nil {
+		return check(statusCode)
+	}
 	return statusCode >= 200 && statusCode <= 299
 }
 
@@ -582,7 +799,7 @@ func (c *httpClient) initResult(result interface{}, resp *fasthttp.Response) err
 	default:
 		body := resp.Body()
 		if body != nil {
-			err := c.jsonUnmarshal(body, result)
+			err := c.codecs.response(string(resp.Header.ContentType())).Unmarshal(body, result)
 			if err != nil {
 				return err
 			}
@@ -622,6 +839,30 @@ func (c *httpClient) getRawBody(data interface{}) (*Raw, bool) {
 	return nil, false
 }
 
+func (c *httpClient) getBody(data interface{}) (*Body, bool) {
+	body, ok := data.(Body)
+	if ok {
+		return &body, true
+	}
+
+	bodyPtr, ok := data.(*Body)
+	if ok {
+		return bodyPtr, true
+	}
+
+	xmlBody, ok := data.(XML)
+	if ok {
+		return &Body{ContentType: HeaderContentTypeXml, Data: xmlBody.Data}, true
+	}
+
+	xmlBodyPtr, ok := data.(*XML)
+	if ok {
+		return &Body{ContentType: HeaderContentTypeXml, Data: xmlBodyPtr.Data}, true
+	}
+
+	return nil, false
+}
+
 func (c *httpClient) getFormUrlEncodedBody(data interface{}) (*FormUrlEncoded, bool) {
 	form, ok := data.(FormUrlEncoded)
 	if ok {
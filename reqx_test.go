@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"github.com/dreamph/reqx"
 	"github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -737,24 +740,28 @@ func Test_Delete_Api_Style(t *testing.T) {
 	}
 }
 
-func Benchmark_ReqxRequests(b *testing.B) {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = fmt.Fprintln(w, ToJsonString(Response{Origin: "reqx"}))
-	}))
-	defer ts.Close()
+// benchmarkURL is served in memory by the benchmarks, so they measure the clients instead of the network.
+const benchmarkURL = "http://bench.local"
+
+var benchmarkHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = fmt.Fprintln(w, ToJsonString(Response{Origin: "reqx"}))
+})
 
+func Benchmark_ReqxRequests(b *testing.B) {
 	client := reqx.New(
 		reqx.WithTimeout(10*time.Second),
 		reqx.WithHeaders(reqx.Headers{
 			reqx.HeaderAuthorization: "Bearer 123456",
 		}),
+		reqx.WithHTTPHandler(benchmarkHandler),
 	)
+	defer reqx.Close(client)
 
 	b.Run("GET", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			result := &Response{}
 			_, _ = client.Get(&reqx.Request{
-				URL:    ts.URL,
+				URL:    benchmarkURL,
 				Result: result,
 			})
 		}
@@ -764,7 +771,7 @@ func Benchmark_ReqxRequests(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			result := &Response{}
 			_, _ = client.Post(&reqx.Request{
-				URL: ts.URL,
+				URL: benchmarkURL,
 				Data: &Data{
 					Name: "Reqx",
 				},
@@ -785,7 +792,7 @@ func Benchmark_ReqxRequests(b *testing.B) {
 			}
 			var resultUploadBytes []byte
 			_, _ = client.Post(&reqx.Request{
-				URL: ts.URL,
+				URL: benchmarkURL,
 				Data: &reqx.Form{
 					FormData: reqx.FormData{
 						"firstName": "reqx",
@@ -815,7 +822,7 @@ func Benchmark_ReqxRequests(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			result := &Response{}
 			_, _ = client.Put(&reqx.Request{
-				URL: ts.URL,
+				URL: benchmarkURL,
 				Data: &Data{
 					Name: "Reqx",
 				},
@@ -828,7 +835,7 @@ func Benchmark_ReqxRequests(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			result := &Response{}
 			_, _ = client.Patch(&reqx.Request{
-				URL: ts.URL,
+				URL: benchmarkURL,
 				Data: &Data{
 					Name: "Reqx",
 				},
@@ -841,7 +848,7 @@ func Benchmark_ReqxRequests(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			result := &Response{}
 			_, _ = client.Delete(&reqx.Request{
-				URL: ts.URL,
+				URL: benchmarkURL,
 				Data: &Data{
 					Name: "Reqx",
 				},
@@ -852,18 +859,25 @@ func Benchmark_ReqxRequests(b *testing.B) {
 }
 
 func Benchmark_GoHttpRequests(b *testing.B) {
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		_, _ = fmt.Fprintln(w, ToJsonString(Response{Origin: "reqx"}))
-	}))
+	ln := fasthttputil.NewInmemoryListener()
+	ts := &http.Server{Handler: benchmarkHandler}
+	go func() {
+		_ = ts.Serve(ln)
+	}()
 	defer ts.Close()
 
 	client := &http.Client{
 		Timeout: time.Second * 10,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+				return ln.Dial()
+			},
+		},
 	}
 
 	b.Run("GET", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+			req, err := http.NewRequest(http.MethodGet, benchmarkURL, nil)
 			if err != nil {
 				log.Fatalln(err)
 			}
@@ -881,7 +895,7 @@ func Benchmark_GoHttpRequests(b *testing.B) {
 
 	b.Run("POST", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			req, err := http.NewRequest(http.MethodPost, ts.URL, bytes.NewReader(ToJsonBytes(Data{
+			req, err := http.NewRequest(http.MethodPost, benchmarkURL, bytes.NewReader(ToJsonBytes(Data{
 				Name: "Reqx",
 			})))
 			if err != nil {
@@ -901,7 +915,7 @@ func Benchmark_GoHttpRequests(b *testing.B) {
 
 	b.Run("PUT", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			req, err := http.NewRequest(http.MethodPut, ts.URL, bytes.NewReader(ToJsonBytes(Data{
+			req, err := http.NewRequest(http.MethodPut, benchmarkURL, bytes.NewReader(ToJsonBytes(Data{
 				Name: "Reqx",
 			})))
 			if err != nil {
@@ -921,7 +935,7 @@ func Benchmark_GoHttpRequests(b *testing.B) {
 
 	b.Run("PATCH", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			req, err := http.NewRequest(http.MethodPatch, ts.URL, bytes.NewReader(ToJsonBytes(Data{
+			req, err := http.NewRequest(http.MethodPatch, benchmarkURL, bytes.NewReader(ToJsonBytes(Data{
 				Name: "Reqx",
 			})))
 			if err != nil {
@@ -941,7 +955,7 @@ func Benchmark_GoHttpRequests(b *testing.B) {
 
 	b.Run("DELETE", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			req, err := http.NewRequest(http.MethodDelete, ts.URL, bytes.NewReader(ToJsonBytes(Data{
+			req, err := http.NewRequest(http.MethodDelete, benchmarkURL, bytes.NewReader(ToJsonBytes(Data{
 				Name: "Reqx",
 			})))
 			if err != nil {
@@ -959,3 +973,23 @@ func Benchmark_GoHttpRequests(b *testing.B) {
 		}
 	})
 }
+
+func Test_BaseURL(t *testing.T) {
+	var hosts []string
+	client := reqx.New(
+		reqx.WithBaseURL("http://api.local"),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			hosts = append(hosts, string(ctx.Host())+string(ctx.Path()))
+		}),
+	)
+
+	for _, url := range []string{"/users", "HTTP://other.local/users"} {
+		_, err := client.Get(&reqx.Request{URL: url})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(hosts) != 2 || hosts[0] != "api.local/users" || hosts[1] != "other.local/users" {
+		t.Errorf("Test_BaseURL Error: %q", hosts)
+	}
+}
@@ -0,0 +1,441 @@
+package reqxtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+var ErrNoExpectation = errors.New("reqxtest: no expectation matches request")
+
+// Call is a request recorded by a MockTransport.
+type Call struct {
+	Method  string
+	URL     string
+	Headers reqx.Headers
+	Body    []byte
+	// Expectation is nil when no expectation matched.
+	Expectation *Expectation
+}
+
+// MockTransport is an in-process reqx.Transport that answers requests from declared expectations
+// and records every call.
+type MockTransport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []*Call
+}
+
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// NewClient returns a reqx client that sends all requests to mock.
+func NewClient(mock *MockTransport, opts ...reqx.ClientOptions) reqx.Client {
+	return reqx.New(append(opts, reqx.WithTransport(mock))...)
+}
+
+// On declares an expectation for method and urlPattern. An empty method or * matches any method.
+// In urlPattern * matches any sequence of characters; a pattern starting with / is matched
+// against the path and query only.
+func (m *MockTransport) On(method string, urlPattern string) *Expectation {
+	e := &Expectation{
+		method:     strings.ToUpper(method),
+		urlPattern: urlPattern,
+		urlRegexp:  globRegexp(urlPattern),
+		times:      -1,
+		status:     http.StatusOK,
+	}
+
+	m.mu.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Calls returns the recorded calls in order.
+func (m *MockTransport) Calls() []*Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Call(nil), m.calls...)
+}
+
+// Reset removes all expectations and recorded calls.
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expectations = nil
+	m.calls = nil
+}
+
+// AssertExpectations fails t for every expectation that was not called the expected number
+// of times and for every request that matched no expectation.
+func (m *MockTransport) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, e := range m.expectations {
+		if e.optional {
+			continue
+		}
+		if e.times < 0 && e.calls == 0 {
+			t.Errorf("reqxtest: expected call %s was not made", e)
+			ok = false
+		}
+		if e.times >= 0 && e.calls != e.times {
+			t.Errorf("reqxtest: expected %d call(s) to %s, got %d", e.times, e, e.calls)
+			ok = false
+		}
+	}
+	for _, call := range m.calls {
+		if call.Expectation == nil {
+			t.Errorf("reqxtest: unexpected call %s %s", call.Method, call.URL)
+			ok = false
+		}
+	}
+	return ok
+}
+
+func (m *MockTransport) Do(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+	call := newCall(req)
+
+	m.mu.Lock()
+	var matched *Expectation
+	for _, e := range m.expectations {
+		if e.exhausted() || !e.matches(req, call) {
+			continue
+		}
+		matched = e
+		break
+	}
+	if matched != nil {
+		matched.calls++
+	}
+	call.Expectation = matched
+	m.calls = append(m.calls, call)
+	m.mu.Unlock()
+
+	if matched == nil {
+		return fmt.Errorf("%w: %s %s", ErrNoExpectation, call.Method, call.URL)
+	}
+	return matched.respond(req, resp)
+}
+
+func newCall(req *reqx.RequestInfo) *Call {
+	headers := reqx.Headers{}
+	req.Header.VisitAll(func(key, value []byte) {
+		headers[string(key)] = string(value)
+	})
+	return &Call{
+		Method:  string(req.Header.Method()),
+		URL:     req.URI().String(),
+		Headers: headers,
+		Body:    append([]byte(nil), req.Body()...),
+	}
+}
+
+// Expectation describes a request the mock should receive and the response it should send.
+type Expectation struct {
+	method     string
+	urlPattern string
+	urlRegexp  *regexp.Regexp
+	headers    reqx.Headers
+	matchers   []func(req *reqx.RequestInfo, call *Call) bool
+	times      int
+	optional   bool
+	calls      int
+
+	status      int
+	respHeaders reqx.Headers
+	body        []byte
+	bodyFile    string
+	bodyFunc    func() ([]byte, error)
+	err         error
+	delay       time.Duration
+	respondFunc func(req *reqx.RequestInfo, resp *fasthttp.Response) error
+}
+
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.urlPattern
+}
+
+func (e *Expectation) WithHeader(key string, value string) *Expectation {
+	if e.headers == nil {
+		e.headers = reqx.Headers{}
+	}
+	e.headers[key] = value
+	return e
+}
+
+func (e *Expectation) WithQuery(key string, value string) *Expectation {
+	return e.WithMatcher(func(req *reqx.RequestInfo, _ *Call) bool {
+		return string(req.URI().QueryArgs().Peek(key)) == value
+	})
+}
+
+// WithJSONBody matches requests whose body is JSON equal to v.
+func (e *Expectation) WithJSONBody(v any) *Expectation {
+	expected, err := normalizeJSON(v)
+	return e.WithMatcher(func(_ *reqx.RequestInfo, call *Call) bool {
+		if err != nil {
+			return false
+		}
+		var actual any
+		if gojson.Unmarshal(call.Body, &actual) != nil {
+			return false
+		}
+		return reflect.DeepEqual(expected, actual)
+	})
+}
+
+func (e *Expectation) WithBody(body string) *Expectation {
+	return e.WithMatcher(func(_ *reqx.RequestInfo, call *Call) bool {
+		return string(call.Body) == body
+	})
+}
+
+func (e *Expectation) WithMatcher(matcher func(req *reqx.RequestInfo, call *Call) bool) *Expectation {
+	e.matchers = append(e.matchers, matcher)
+	return e
+}
+
+// Times limits the expectation to n calls. AssertExpectations checks it was called exactly n times.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// Maybe makes the expectation optional for AssertExpectations.
+func (e *Expectation) Maybe() *Expectation {
+	e.optional = true
+	return e
+}
+
+func (e *Expectation) Reply(status int) *Expectation {
+	e.status = status
+	return e
+}
+
+func (e *Expectation) ReplyHeader(key string, value string) *Expectation {
+	if e.respHeaders == nil {
+		e.respHeaders = reqx.Headers{}
+	}
+	e.respHeaders[key] = value
+	return e
+}
+
+func (e *Expectation) ReplyBody(body []byte) *Expectation {
+	e.body = body
+	return e
+}
+
+func (e *Expectation) ReplyString(status int, body string) *Expectation {
+	e.status = status
+	e.body = []byte(body)
+	return e
+}
+
+// ReplyJSON answers with v encoded as JSON.
+func (e *Expectation) ReplyJSON(status int, v any) *Expectation {
+	e.status = status
+	e.bodyFunc = func() ([]byte, error) {
+		return gojson.Marshal(v)
+	}
+	return e.ReplyHeader(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+}
+
+// ReplyFile answers with the contents of the file at path. The content type is derived from the extension.
+func (e *Expectation) ReplyFile(status int, path string) *Expectation {
+	e.status = status
+	e.bodyFile = path
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		e.ReplyHeader(reqx.HeaderContentType, contentType)
+	}
+	return e
+}
+
+// ReplyError makes the transport fail with err, as a network error would.
+func (e *Expectation) ReplyError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// ReplyTimeout makes the transport fail with fasthttp.ErrTimeout.
+func (e *Expectation) ReplyTimeout() *Expectation {
+	return e.ReplyError(fasthttp.ErrTimeout)
+}
+
+// Delay waits before responding. When the request timeout or context ends first,
+// the call fails with fasthttp.ErrTimeout or the context error.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// ReplyFunc answers with a custom function.
+func (e *Expectation) ReplyFunc(f func(req *reqx.RequestInfo, resp *fasthttp.Response) error) *Expectation {
+	e.respondFunc = f
+	return e
+}
+
+func (e *Expectation) exhausted() bool {
+	return e.times >= 0 && e.calls >= e.times
+}
+
+func (e *Expectation) matches(req *reqx.RequestInfo, call *Call) bool {
+	if e.method != "" && e.method != "*" && e.method != call.Method {
+		return false
+	}
+
+	target := call.URL
+	if strings.HasPrefix(e.urlPattern, "/") {
+		target = string(req.URI().RequestURI())
+	}
+	if !e.urlRegexp.MatchString(target) {
+		return false
+	}
+
+	for k, v := range e.headers {
+		if !hasHeader(req, k, v) {
+			return false
+		}
+	}
+
+	for _, matcher := range e.matchers {
+		if !matcher(req, call) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) respond(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+	if e.delay > 0 {
+		err := wait(req, e.delay)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	if e.respondFunc != nil {
+		return e.respondFunc(req, resp)
+	}
+
+	resp.SetStatusCode(e.status)
+	for k, v := range e.respHeaders {
+		resp.Header.Set(k, v)
+	}
+
+	body := e.body
+	switch {
+	case e.bodyFunc != nil:
+		b, err := e.bodyFunc()
+		if err != nil {
+			return err
+		}
+		body = b
+	case e.bodyFile != "":
+		b, err := os.ReadFile(e.bodyFile)
+		if err != nil {
+			return err
+		}
+		body = b
+	}
+	resp.SetBody(body)
+	return nil
+}
+
+func wait(req *reqx.RequestInfo, delay time.Duration) error {
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	timeout := req.GetTimeOut()
+	if timeout > 0 && timeout < delay {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			return fasthttp.ErrTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func hasHeader(req *reqx.RequestInfo, key string, value string) bool {
+	found := false
+	req.Header.VisitAll(func(k, v []byte) {
+		if strings.EqualFold(string(k), key) && string(v) == value {
+			found = true
+		}
+	})
+	return found
+}
+
+func normalizeJSON(v any) (any, error) {
+	var data []byte
+	switch b := v.(type) {
+	case []byte:
+		data = b
+	case string:
+		data = []byte(b)
+	default:
+		var err error
+		data, err = gojson.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var normalized any
+	err := gojson.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func globRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
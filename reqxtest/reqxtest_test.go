@@ -0,0 +1,148 @@
+package reqxtest_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/reqxtest"
+)
+
+type Data struct {
+	Name string `json:"name"`
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_MockTransport(t *testing.T) {
+	mock := reqxtest.NewMockTransport()
+	mock.On(http.MethodPost, "/users*").
+		WithHeader("X-Api-Key", "secret").
+		WithQuery("notify", "true").
+		WithJSONBody(`{"name": "reqx"}`).
+		Once().
+		ReplyJSON(http.StatusCreated, &Data{Name: "created"})
+	mock.On(http.MethodGet, "https://api.example.com/users/*").
+		ReplyString(http.StatusOK, `{"name":"reqx"}`)
+
+	client := reqxtest.NewClient(mock,
+		reqx.WithBaseURL("https://api.example.com"),
+		reqx.WithHeaders(reqx.Headers{"X-Api-Key": "secret"}),
+	)
+
+	result := &Data{}
+	resp, err := client.Post(&reqx.Request{
+		URL:    "/users?notify=true",
+		Data:   &Data{Name: "reqx"},
+		Result: result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated || result.Name != "created" {
+		t.Errorf("Test_MockTransport Error: %d %+v", resp.StatusCode, result)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err = client.Get(&reqx.Request{
+			URL:    "/users/1",
+			Result: result,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || result.Name != "reqx" {
+			t.Errorf("Test_MockTransport Error: %d %+v", resp.StatusCode, result)
+		}
+	}
+
+	// The POST expectation is used up.
+	_, err = client.Post(&reqx.Request{
+		URL:  "/users?notify=true",
+		Data: &Data{Name: "reqx"},
+	})
+	if !errors.Is(err, reqxtest.ErrNoExpectation) {
+		t.Errorf("Test_MockTransport Error: %v", err)
+	}
+
+	calls := mock.Calls()
+	if len(calls) != 4 || calls[0].URL != "https://api.example.com/users?notify=true" || string(calls[0].Body) != `{"name":"reqx"}` {
+		t.Errorf("Test_MockTransport Error: calls %+v", calls)
+	}
+	if calls[3].Expectation != nil {
+		t.Errorf("Test_MockTransport Error: unmatched call has expectation %s", calls[3].Expectation)
+	}
+
+	rec := &recorder{TB: t}
+	if mock.AssertExpectations(rec) || len(rec.errors) != 1 {
+		t.Errorf("Test_MockTransport Error: AssertExpectations reported %q", rec.errors)
+	}
+}
+
+func Test_MockTransport_ReplyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.json")
+	err := os.WriteFile(path, []byte(`{"name":"file"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock := reqxtest.NewMockTransport()
+	mock.On(http.MethodGet, "*/user").ReplyFile(http.StatusOK, path)
+	client := reqxtest.NewClient(mock)
+
+	result := &Data{}
+	resp, err := client.Get(&reqx.Request{
+		URL:    "http://localhost/user",
+		Result: result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Name != "file" || resp.Headers[reqx.HeaderContentType] != reqx.HeaderContentTypeJson {
+		t.Errorf("Test_MockTransport_ReplyFile Error: %+v %v", result, resp.Headers)
+	}
+	mock.AssertExpectations(t)
+}
+
+func Test_MockTransport_Faults(t *testing.T) {
+	mock := reqxtest.NewMockTransport()
+	mock.On(http.MethodGet, "/timeout").ReplyTimeout()
+	mock.On(http.MethodGet, "/slow").Delay(time.Second).Reply(http.StatusOK)
+	mock.On(http.MethodGet, "/down").ReplyError(errors.New("connection refused"))
+	mock.On(http.MethodGet, "/unused").Maybe()
+	client := reqxtest.NewClient(mock, reqx.WithBaseURL("http://localhost"))
+
+	_, err := client.Get(&reqx.Request{URL: "/timeout"})
+	if !errors.Is(err, fasthttp.ErrTimeout) {
+		t.Errorf("Test_MockTransport_Faults Error: timeout %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Get(&reqx.Request{URL: "/slow", Timeout: 10 * time.Millisecond})
+	if !errors.Is(err, fasthttp.ErrTimeout) || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Test_MockTransport_Faults Error: slow %v after %s", err, time.Since(start))
+	}
+
+	_, err = client.Get(&reqx.Request{URL: "/down"})
+	if err == nil || err.Error() != "connection refused" {
+		t.Errorf("Test_MockTransport_Faults Error: down %v", err)
+	}
+
+	mock.AssertExpectations(t)
+}
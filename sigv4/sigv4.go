@@ -0,0 +1,328 @@
+package sigv4
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dreamph/reqx"
+)
+
+const (
+	algorithm       = "AWS4-HMAC-SHA256"
+	scopeTerminator = "aws4_request"
+	timeFormat      = "20060102T150405Z"
+	dateFormat      = "20060102"
+
+	// UnsignedPayload is sent as the payload hash when the body is not signed.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	HeaderAmzDate          = "X-Amz-Date"
+	HeaderAmzContentSha256 = "X-Amz-Content-Sha256"
+	HeaderAmzSecurityToken = "X-Amz-Security-Token"
+)
+
+var (
+	ErrMissingCredentials = errors.New("sigv4: missing access key id or secret access key")
+	ErrExpiredCredentials = errors.New("sigv4: credentials expired")
+)
+
+// ignoredHeaders are not signed because proxies and the transport may rewrite them.
+var ignoredHeaders = map[string]struct{}{
+	"authorization":     {},
+	"user-agent":        {},
+	"x-amzn-trace-id":   {},
+	"expect":            {},
+	"transfer-encoding": {},
+	"content-length":    {},
+	"connection":        {},
+}
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is set for temporary credentials, for example from STS.
+	SessionToken string
+	// Expires fails signing with ErrExpiredCredentials from this time on, unless zero.
+	Expires time.Time
+}
+
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (Credentials, error)
+}
+
+type CredentialsProviderFunc func(ctx context.Context) (Credentials, error)
+
+func (f CredentialsProviderFunc) Retrieve(ctx context.Context) (Credentials, error) {
+	return f(ctx)
+}
+
+type StaticCredentials Credentials
+
+func (c StaticCredentials) Retrieve(_ context.Context) (Credentials, error) {
+	return Credentials(c), nil
+}
+
+type Options struct {
+	Credentials CredentialsProvider
+	Region      string
+	Service     string
+	// UnsignedPayload skips hashing the body. Streamed bodies are always sent unsigned.
+	UnsignedPayload bool
+	// DisableURIPathEscaping signs the path escaped once instead of twice. S3 requires this
+	// and it is enabled automatically for the s3 service.
+	DisableURIPathEscaping bool
+	// Now overrides the signing time, mainly for tests.
+	Now func() time.Time
+}
+
+type Signer struct {
+	opts Options
+
+	mu        sync.Mutex
+	keyDate   string
+	keySecret string
+	key       []byte
+}
+
+func New(opts Options) *Signer {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.Service == "s3" {
+		opts.DisableURIPathEscaping = true
+	}
+	return &Signer{
+		opts: opts,
+	}
+}
+
+func (s *Signer) Sign(req *reqx.RequestInfo) error {
+	ctx := req.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if s.opts.Credentials == nil {
+		return ErrMissingCredentials
+	}
+	credentials, err := s.opts.Credentials.Retrieve(ctx)
+	if err != nil {
+		return err
+	}
+	if credentials.AccessKeyID == "" || credentials.SecretAccessKey == "" {
+		return ErrMissingCredentials
+	}
+
+	now := s.opts.Now().UTC()
+	if !credentials.Expires.IsZero() && !now.Before(credentials.Expires) {
+		return ErrExpiredCredentials
+	}
+	amzDate := now.Format(timeFormat)
+	date := now.Format(dateFormat)
+
+	req.Header.Del(reqx.HeaderAuthorization)
+	req.Header.Set(HeaderAmzDate, amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set(HeaderAmzSecurityToken, credentials.SessionToken)
+	} else {
+		req.Header.Del(HeaderAmzSecurityToken)
+	}
+
+	payloadHash := s.payloadHash(req)
+	if s.opts.Service == "s3" || payloadHash == UnsignedPayload {
+		req.Header.Set(HeaderAmzContentSha256, payloadHash)
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		string(req.Header.Method()),
+		s.canonicalURI(req),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.opts.Region, s.opts.Service, scopeTerminator}, "/")
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signature := hex.EncodeToString(hmacSHA256(s.signingKey(credentials.SecretAccessKey, date), []byte(stringToSign)))
+
+	req.Header.Set(reqx.HeaderAuthorization, algorithm+
+		" Credential="+credentials.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+func (s *Signer) payloadHash(req *reqx.RequestInfo) string {
+	if s.opts.UnsignedPayload || req.IsBodyStream() {
+		return UnsignedPayload
+	}
+	return hashHex(req.Body())
+}
+
+func (s *Signer) signingKey(secret string, date string) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keyDate == date && s.keySecret == secret {
+		return s.key
+	}
+
+	key := hmacSHA256([]byte("AWS4"+secret), []byte(date))
+	key = hmacSHA256(key, []byte(s.opts.Region))
+	key = hmacSHA256(key, []byte(s.opts.Service))
+	key = hmacSHA256(key, []byte(scopeTerminator))
+
+	s.keyDate, s.keySecret, s.key = date, secret, key
+	return key
+}
+
+func (s *Signer) canonicalURI(req *reqx.RequestInfo) string {
+	path := string(req.URI().PathOriginal())
+	if path == "" {
+		return "/"
+	}
+
+	canonical := escapePath(path, true)
+	if !s.opts.DisableURIPathEscaping {
+		canonical = escapePath(canonical, false)
+	}
+	return canonical
+}
+
+func canonicalQuery(req *reqx.RequestInfo) string {
+	type pair struct {
+		key   string
+		value string
+	}
+
+	var pairs []pair
+	req.URI().QueryArgs().VisitAll(func(key, value []byte) {
+		pairs = append(pairs, pair{
+			key:   escape(string(key)),
+			value: escape(string(value)),
+		})
+	})
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].key == pairs[j].key {
+			return pairs[i].value < pairs[j].value
+		}
+		return pairs[i].key < pairs[j].key
+	})
+
+	var sb strings.Builder
+	for i, p := range pairs {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(p.key)
+		sb.WriteByte('=')
+		sb.WriteString(p.value)
+	}
+	return sb.String()
+}
+
+func canonicalizeHeaders(req *reqx.RequestInfo) (string, string) {
+	headers := map[string][]string{
+		"host": {string(req.Host())},
+	}
+	req.Header.VisitAll(func(key, value []byte) {
+		name := strings.ToLower(string(key))
+		if name == "host" {
+			return
+		}
+		if _, ok := ignoredHeaders[name]; ok {
+			return
+		}
+		headers[name] = append(headers[name], trimHeaderValue(string(value)))
+	})
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte(':')
+		sb.WriteString(strings.Join(headers[name], ","))
+		sb.WriteByte('\n')
+	}
+	return sb.String(), strings.Join(names, ";")
+}
+
+// trimHeaderValue trims the value and collapses sequential spaces into one.
+func trimHeaderValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// escapePath escapes each path segment. With normalize the segment is unescaped first;
+// segments are split before that so an encoded slash stays part of its segment.
+func escapePath(path string, normalize bool) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if normalize {
+			unescaped, err := url.PathUnescape(segment)
+			if err == nil {
+				segment = unescaped
+			}
+		}
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything except the RFC 3986 unreserved characters.
+func escape(s string) string {
+	const hexUpper = "0123456789ABCDEF"
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isUnreserved(c) {
+			sb.WriteByte(c)
+			continue
+		}
+		sb.WriteByte('%')
+		sb.WriteByte(hexUpper[c>>4])
+		sb.WriteByte(hexUpper[c&15])
+	}
+	return sb.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' ||
+		'a' <= c && c <= 'z' ||
+		'0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
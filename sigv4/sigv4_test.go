@@ -0,0 +1,178 @@
+package sigv4_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/sigv4"
+)
+
+var testTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func newTestSigner(opts sigv4.Options) *sigv4.Signer {
+	if opts.Credentials == nil {
+		opts.Credentials = sigv4.StaticCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		}
+	}
+	opts.Region = "us-east-1"
+	if opts.Service == "" {
+		opts.Service = "service"
+	}
+	opts.Now = func() time.Time {
+		return testTime
+	}
+	return sigv4.New(opts)
+}
+
+func sign(t *testing.T, signer *sigv4.Signer, method string, uri string, body []byte, headers reqx.Headers) *fasthttp.Request {
+	req := fasthttp.AcquireRequest()
+	req.Header.DisableNormalizing()
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	if body != nil {
+		req.SetBody(body)
+	}
+
+	err := signer.Sign(&reqx.RequestInfo{
+		Request: req,
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+// The expected signatures come from the AWS Signature Version 4 test suite.
+func Test_Sign_TestSuite(t *testing.T) {
+	tests := []struct {
+		name          string
+		uri           string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get-vanilla",
+			uri:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			uri:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sign(t, newTestSigner(sigv4.Options{}), fasthttp.MethodGet, tt.uri, nil, nil)
+			defer fasthttp.ReleaseRequest(req)
+
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=" + tt.signedHeaders + ", Signature=" + tt.signature
+			if got := string(req.Header.Peek(reqx.HeaderAuthorization)); got != want {
+				t.Errorf("Authorization = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func Test_Sign_SessionTokenAndUnsignedPayload(t *testing.T) {
+	signer := newTestSigner(sigv4.Options{
+		Credentials: sigv4.StaticCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			SessionToken:    "session",
+		},
+		Service:         "s3",
+		UnsignedPayload: true,
+	})
+
+	req := sign(t, signer, fasthttp.MethodPut, "https://bucket.s3.amazonaws.com/my%20key", []byte("data"), reqx.Headers{
+		reqx.HeaderContentType: "text/plain",
+	})
+	defer fasthttp.ReleaseRequest(req)
+
+	if got := string(req.Header.Peek(sigv4.HeaderAmzSecurityToken)); got != "session" {
+		t.Errorf("X-Amz-Security-Token = %s", got)
+	}
+	if got := string(req.Header.Peek(sigv4.HeaderAmzContentSha256)); got != sigv4.UnsignedPayload {
+		t.Errorf("X-Amz-Content-Sha256 = %s", got)
+	}
+	authorization := string(req.Header.Peek(reqx.HeaderAuthorization))
+	if !strings.Contains(authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %s", authorization)
+	}
+}
+
+func Test_Sign_Client(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get(reqx.HeaderAuthorization), "AWS4-HMAC-SHA256 ") || r.Header.Get(sigv4.HeaderAmzDate) == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithSigner(sigv4.New(sigv4.Options{
+			Credentials: sigv4.StaticCredentials{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			},
+			Region:  "us-east-1",
+			Service: "es",
+		})),
+	)
+
+	resp, err := client.Post(&reqx.Request{
+		URL:  ts.URL + "/index/_doc",
+		Data: map[string]string{"name": "reqx"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Test_Sign_Client Error: status %d", resp.StatusCode)
+	}
+}
+
+func Test_Sign_InvalidCredentials(t *testing.T) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	req.SetRequestURI("https://example.amazonaws.com/")
+	reqInfo := &reqx.RequestInfo{Request: req, Context: context.Background()}
+
+	err := sigv4.New(sigv4.Options{Region: "us-east-1", Service: "service"}).Sign(reqInfo)
+	if !errors.Is(err, sigv4.ErrMissingCredentials) {
+		t.Errorf("Test_Sign_InvalidCredentials Error: nil provider %v", err)
+	}
+
+	err = newTestSigner(sigv4.Options{
+		Credentials: sigv4.StaticCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+			Expires:         testTime,
+		},
+	}).Sign(reqInfo)
+	if !errors.Is(err, sigv4.ErrExpiredCredentials) {
+		t.Errorf("Test_Sign_InvalidCredentials Error: expired %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package reqx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	HeaderContentTypeEventStream = "text/event-stream"
+	HeaderLastEventID            = "Last-Event-ID"
+
+	// DefaultSSERetry is the reconnection delay until the server sets one with a retry field.
+	DefaultSSERetry = 3 * time.Second
+	// DefaultSSEMaxReconnects is the number of failed reconnections in a row after which a stream stops.
+	DefaultSSEMaxReconnects = 10
+
+	sseMaxLineSize = 1 << 20
+)
+
+var ErrSSEUnexpectedResponse = errors.New("reqx: unexpected event stream response")
+
+// Event is a server-sent event. Event is "message" unless the server sets the event type.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	// Err is set on the last event, sent before the channel is closed, when the stream stops on an error.
+	Err error
+}
+
+type SSEOption struct {
+	// MaxReconnects stops the stream after this many failed reconnections in a row,
+	// DefaultSSEMaxReconnects when 0. A negative value reconnects until ctx is done.
+	MaxReconnects int
+}
+
+type SSEOptions func(opts *SSEOption)
+
+func WithSSEMaxReconnects(maxReconnects int) SSEOptions {
+	return func(opts *SSEOption) {
+		opts.MaxReconnects = maxReconnects
+	}
+}
+
+// SSEClient is implemented by clients that open event streams, such as the clients of New.
+// Wrappers of a Client implement it to support SSE.
+type SSEClient interface {
+	SSE(ctx context.Context, request *Request, opts ...SSEOptions) (<-chan Event, error)
+}
+
+// SSE opens an event stream with a GET request, or a POST request when request.Data is set,
+// and sends its events on the returned channel until ctx is done.
+// When the stream ends or the connection fails the request is sent again after the retry delay with Last-Event-ID.
+// A response other than 200 with text/event-stream fails the first request with ErrSSEUnexpectedResponse,
+// decoding the body into request.ErrorResult. Only failures of the transport are reconnected, up to
+// SSEOption.MaxReconnects times in a row. A later 204 response closes the channel. Other errors, e.g. of the
+// Signer or ErrSSEUnexpectedResponse on a later request, stop the stream: the error is sent as the Err of a
+// last event and the channel is closed.
+// request.Timeout does not apply to the stream.
+// It returns ErrUnsupportedClient when client does not implement SSEClient.
+func SSE(ctx context.Context, client Client, request *Request, opts ...SSEOptions) (<-chan Event, error) {
+	c, ok := client.(SSEClient)
+	if !ok {
+		return nil, ErrUnsupportedClient
+	}
+	return c.SSE(ctx, request, opts...)
+}
+
+func (c *httpClient) SSE(ctx context.Context, request *Request, opts ...SSEOptions) (<-chan Event, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opt := &SSEOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.MaxReconnects == 0 {
+		opt.MaxReconnects = DefaultSSEMaxReconnects
+	}
+	s := &sseStream{
+		client:  c,
+		request: request,
+		opt:     opt,
+		retry:   DefaultSSERetry,
+	}
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go s.run(ctx, conn, events)
+	return events, nil
+}
+
+type sseStream struct {
+	client      *httpClient
+	request     *Request
+	opt         *SSEOption
+	lastEventID string
+	retry       time.Duration
+	ended       bool
+}
+
+func (s *sseStream) connect(ctx context.Context) (*streamedResponse, error) {
+	method := http.MethodGet
+	if s.request.Data != nil {
+		method = http.MethodPost
+	}
+
+	isOK := func(statusCode int) bool {
+		return statusCode == http.StatusOK
+	}
+	conn, err := s.client.openStream(ctx, method, s.request, isOK, func(req *fasthttp.Request) {
+		req.Header.Set(fasthttp.HeaderAccept, HeaderContentTypeEventStream)
+		req.Header.Set(fasthttp.HeaderCacheControl, "no-cache")
+		if string(req.Header.Peek(HeaderAcceptEncoding)) == DefaultAcceptEncoding {
+			req.Header.Del(HeaderAcceptEncoding)
+		}
+		if s.lastEventID != "" {
+			req.Header.Set(HeaderLastEventID, s.lastEventID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A server answers 204 to end the stream.
+	s.ended = conn.resp.StatusCode() == http.StatusNoContent
+	if !isOK(conn.resp.StatusCode()) || mediaType(string(conn.resp.Header.ContentType())) != HeaderContentTypeEventStream {
+		err = fmt.Errorf("%w: status %d, content type %q", ErrSSEUnexpectedResponse, conn.resp.StatusCode(), conn.resp.Header.ContentType())
+		conn.close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *sseStream) run(ctx context.Context, conn *streamedResponse, events chan<- Event) {
+	defer close(events)
+
+	for {
+		err := s.read(ctx, conn.body(), events)
+		conn.close()
+		if err != nil {
+			s.stop(ctx, err, events)
+			return
+		}
+
+		for failures := 0; ; failures++ {
+			timer := time.NewTimer(s.retry)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			conn, err = s.connect(ctx)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil || s.ended {
+				return
+			}
+			var transportErr *transportError
+			if !errors.As(err, &transportErr) || (s.opt.MaxReconnects > 0 && failures+1 >= s.opt.MaxReconnects) {
+				s.stop(ctx, err, events)
+				return
+			}
+		}
+	}
+}
+
+// stop sends err as the last event unless ctx is done.
+func (s *sseStream) stop(ctx context.Context, err error, events chan<- Event) {
+	select {
+	case events <- Event{ID: s.lastEventID, Err: err}:
+	case <-ctx.Done():
+	}
+}
+
+// read dispatches the events of body as described by the WHATWG event stream interpretation
+// until body ends or ctx is done. It fails for a line longer than sseMaxLineSize.
+func (s *sseStream) read(ctx context.Context, body io.Reader, events chan<- Event) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), sseMaxLineSize)
+	scanner.Split(scanSSELines)
+
+	var data strings.Builder
+	eventType := ""
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF")
+			first = false
+		}
+
+		if line == "" {
+			if data.Len() == 0 {
+				eventType = ""
+				continue
+			}
+			event := Event{
+				ID:    s.lastEventID,
+				Event: eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+			}
+			if event.Event == "" {
+				event.Event = "message"
+			}
+			data.Reset()
+			eventType = ""
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		case "retry":
+			ms, err := strconv.Atoi(value)
+			if err == nil && ms >= 0 && strings.Trim(value, "0123456789") == "" {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		// The connection ended otherwise, reading the stream again would fail again.
+		return scanner.Err()
+	}
+	return nil
+}
+
+// scanSSELines splits lines ended by CRLF, LF or CR.
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if !atEOF {
+			// A LF may follow the CR.
+			return 0, nil, nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
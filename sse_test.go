@@ -0,0 +1,223 @@
+package reqx_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func Test_SSE(t *testing.T) {
+	var lastEventIDs []string
+	client := reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+		lastEventIDs = append(lastEventIDs, string(ctx.Request.Header.Peek(reqx.HeaderLastEventID)))
+		if len(lastEventIDs) > 2 {
+			ctx.SetStatusCode(http.StatusNoContent)
+			return
+		}
+		if string(ctx.Request.Header.Peek(fasthttp.HeaderAccept)) != reqx.HeaderContentTypeEventStream {
+			t.Errorf("Test_SSE Error: Accept %s", ctx.Request.Header.Peek(fasthttp.HeaderAccept))
+		}
+		ctx.SetContentType(reqx.HeaderContentTypeEventStream)
+		first := len(lastEventIDs) == 1
+		ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+			if first {
+				_, _ = w.WriteString("\uFEFF: comment\nretry: 10\n\ndata: hello\ndata:world\n\n")
+				_ = w.Flush()
+				_, _ = w.WriteString("event: token\r\nid: 1\r\ndata: {\"text\":\"reqx\"}\r\n\r\nid\ndata: partial")
+				return
+			}
+			_, _ = w.WriteString("data: resumed\rid: 2\r\r")
+		})
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := reqx.SSE(ctx, client, &reqx.Request{URL: "http://api.local/events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []reqx.Event
+	for event := range events {
+		got = append(got, event)
+	}
+	want := []reqx.Event{
+		{Event: "message", Data: "hello\nworld"},
+		{ID: "1", Event: "token", Data: `{"text":"reqx"}`},
+		{ID: "2", Event: "message", Data: "resumed"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Test_SSE Error: events %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Test_SSE Error: event %+v, want %+v", got[i], want[i])
+		}
+	}
+	// The second stream ends after "id" reset the last event ID.
+	if len(lastEventIDs) != 3 || lastEventIDs[0] != "" || lastEventIDs[1] != "" || lastEventIDs[2] != "2" {
+		t.Errorf("Test_SSE Error: Last-Event-ID %q", lastEventIDs)
+	}
+}
+
+func Test_SSE_Cancel(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	client := reqx.New(
+		reqx.WithTimeout(50*time.Millisecond),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetContentType(reqx.HeaderContentTypeEventStream)
+			ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				_, _ = w.WriteString("data: first\n\n")
+				_ = w.Flush()
+				<-done
+			})
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := reqx.SSE(ctx, client, &reqx.Request{URL: "http://api.local/events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := <-events
+	if event.Data != "first" {
+		t.Errorf("Test_SSE_Cancel Error: %+v", event)
+	}
+
+	// The stream outlives the client timeout.
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Test_SSE_Cancel Error: unexpected event")
+		}
+	case <-time.After(time.Second):
+		t.Error("Test_SSE_Cancel Error: channel not closed")
+	}
+}
+
+func Test_SSE_UnexpectedResponse(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusUnauthorized)
+		ctx.SetContentType(reqx.HeaderContentTypeJson)
+		ctx.SetBodyString(`{"name":"unauthorized"}`)
+	}))
+
+	var errorResult Data
+	_, err := reqx.SSE(context.Background(), client, &reqx.Request{
+		URL:         "http://api.local/events",
+		Data:        &Data{Name: "prompt"},
+		ErrorResult: &errorResult,
+	})
+	if !errors.Is(err, reqx.ErrSSEUnexpectedResponse) || errorResult.Name != "unauthorized" {
+		t.Errorf("Test_SSE_UnexpectedResponse Error: %v %+v", err, errorResult)
+	}
+}
+
+func Test_SSE_HookReadsBody(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	var body []byte
+	var contentType string
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetContentType(reqx.HeaderContentTypeEventStream)
+			ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+				_, _ = w.WriteString("data: first\n\n")
+				_ = w.Flush()
+				<-done
+			})
+		}),
+		// The hook gets the response headers only while the body is streamed, so reading the body does not block.
+		reqx.WithOnRequestCompleted(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			body = resp.Body()
+			contentType = string(resp.Header.ContentType())
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := reqx.SSE(ctx, client, &reqx.Request{URL: "http://api.local/events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := <-events
+	if event.Data != "first" {
+		t.Errorf("Test_SSE_HookReadsBody Error: %+v", event)
+	}
+	if len(body) != 0 || contentType != reqx.HeaderContentTypeEventStream {
+		t.Errorf("Test_SSE_HookReadsBody Error: body %q, content type %q", body, contentType)
+	}
+}
+
+func Test_SSE_Errors(t *testing.T) {
+	handler := func(ctx *fasthttp.RequestCtx) {
+		ctx.SetContentType(reqx.HeaderContentTypeEventStream)
+		ctx.SetBodyString("retry: 10\nid: 1\ndata: first\n\n")
+	}
+
+	// The Signer fails on the reconnection, which is not retried.
+	signErr := errors.New("credentials expired")
+	signs := 0
+	client := reqx.New(
+		reqx.WithHandler(handler),
+		reqx.WithSigner(reqx.SignerFunc(func(req *reqx.RequestInfo) error {
+			signs++
+			if signs > 1 {
+				return signErr
+			}
+			return nil
+		})),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := reqx.SSE(ctx, client, &reqx.Request{URL: "http://api.local/events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []reqx.Event
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 || got[0].Data != "first" || !errors.Is(got[1].Err, signErr) || got[1].ID != "1" || signs != 2 {
+		t.Errorf("Test_SSE_Errors Error: events %+v, %d signs", got, signs)
+	}
+
+	// Failures of the transport are reconnected up to MaxReconnects times in a row.
+	transportErr := errors.New("connection refused")
+	sends := 0
+	client = reqx.New(
+		reqx.WithHandler(handler),
+		reqx.WithTransportMiddleware(func(next reqx.Transport) reqx.Transport {
+			return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+				sends++
+				if sends > 1 {
+					return transportErr
+				}
+				return next.Do(req, resp)
+			})
+		}),
+	)
+	events, err = reqx.SSE(ctx, client, &reqx.Request{URL: "http://api.local/events"}, reqx.WithSSEMaxReconnects(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for event := range events {
+		got = append(got, event)
+	}
+	if len(got) != 2 || !errors.Is(got[1].Err, transportErr) || sends != 4 {
+		t.Errorf("Test_SSE_Errors Error: events %+v, %d sends", got, sends)
+	}
+}
@@ -0,0 +1,231 @@
+package reqx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+var ErrUnexpectedStatus = errors.New("reqx: unexpected status code")
+
+// StreamResponse is a response whose body is read as it arrives.
+type StreamResponse struct {
+	StatusCode int
+	Headers    Headers
+	// Body is the decompressed response body.
+	Body io.Reader
+
+	stream  *streamedResponse
+	closers []io.Closer
+	success bool
+	codecs  *codecs
+}
+
+// IsSuccess reports whether StatusCode is a success for the request.
+func (r *StreamResponse) IsSuccess() bool {
+	return r.success
+}
+
+// Close stops reading the body and closes the connection.
+func (r *StreamResponse) Close() error {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		_ = r.closers[i].Close()
+	}
+	r.closers = nil
+	if r.stream != nil {
+		r.stream.close()
+		r.stream = nil
+	}
+	return nil
+}
+
+func (r *StreamResponse) jsonCodec() Codec {
+	if r.codecs == nil {
+		return newCodecs(&ClientOption{}).json
+	}
+	return r.codecs.json
+}
+
+// StreamClient is implemented by clients that stream response bodies, such as the clients of New.
+// Wrappers of a Client implement it to support Stream, NDJSON and JSONArray.
+type StreamClient interface {
+	Stream(ctx context.Context, method string, request *Request) (*StreamResponse, error)
+}
+
+// Stream sends request and returns the response before its body is read, so large or endless bodies can be
+// consumed as they arrive. The client timeout does not apply to the body, cancel ctx to stop reading it.
+// The body of an unsuccessful status code is decoded into request.ErrorResult. Close must be called.
+// It returns ErrUnsupportedClient when client does not implement StreamClient.
+func Stream(ctx context.Context, client Client, method string, request *Request) (*StreamResponse, error) {
+	c, ok := client.(StreamClient)
+	if !ok {
+		return nil, ErrUnsupportedClient
+	}
+	return c.Stream(ctx, method, request)
+}
+
+func (c *httpClient) Stream(ctx context.Context, method string, request *Request) (*StreamResponse, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s, err := c.openStream(ctx, method, request, request.ResultSuccessCheckFunc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &StreamResponse{
+		StatusCode: s.resp.StatusCode(),
+		Headers:    getResponseHeaders(s.resp),
+		stream:     s,
+		success:    c.isResultSuccess(request, s.resp.StatusCode()),
+		codecs:     c.codecs,
+	}
+	body := s.body()
+	if encodings, ok := contentEncodings(s.resp); ok && !c.disableDecompression {
+		for _, encoding := range encodings {
+			rc, err := newDecompressReader(encoding, body, 0)
+			if err != nil {
+				_ = r.Close()
+				return nil, err
+			}
+			r.closers = append(r.closers, rc)
+			body = rc
+		}
+		delete(r.Headers, HeaderContentEncoding)
+	}
+	r.Body = body
+	return r, nil
+}
+
+// streamSuccess streams request and fails with ErrUnexpectedStatus for an unsuccessful status code.
+func streamSuccess(ctx context.Context, client Client, method string, request *Request) (*StreamResponse, error) {
+	resp, err := Stream(ctx, client, method, request)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		_ = resp.Close()
+		return nil, fmt.Errorf("%w: %d", ErrUnexpectedStatus, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// streamedResponse is a response with its body left in the connection.
+type streamedResponse struct {
+	req  *fasthttp.Request
+	resp *fasthttp.Response
+	conn *streamConn
+	stop func() bool
+}
+
+func (s *streamedResponse) body() io.Reader {
+	if s.resp.IsBodyStream() {
+		return s.resp.BodyStream()
+	}
+	// Transports without streaming support and error responses read the whole body.
+	return bytes.NewReader(s.resp.Body())
+}
+
+func (s *streamedResponse) close() {
+	s.stop()
+	_ = s.conn.Close()
+	_ = s.resp.CloseBodyStream()
+	fasthttp.ReleaseRequest(s.req)
+	fasthttp.ReleaseResponse(s.resp)
+}
+
+// openStream sends request with a streamed response body, closed when ctx is done.
+// setHeaders adjusts the initialized request. An unsuccessful status code decodes the body
+// into request.ErrorResult. The hooks are called as described by streamCompleted.
+func (c *httpClient) openStream(ctx context.Context, method string, request *Request, successCheck func(statusCode int) bool, setHeaders func(req *fasthttp.Request)) (*streamedResponse, error) {
+	s := &streamedResponse{
+		req:  fasthttp.AcquireRequest(),
+		resp: fasthttp.AcquireResponse(),
+		conn: &streamConn{},
+	}
+	s.stop = context.AfterFunc(ctx, func() {
+		_ = s.conn.Close()
+	})
+
+	err := c.sendStream(ctx, s, method, request, successCheck, setHeaders)
+	if err != nil {
+		s.close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *httpClient) sendStream(ctx context.Context, s *streamedResponse, method string, request *Request, successCheck func(statusCode int) bool, setHeaders func(req *fasthttp.Request)) error {
+	req, resp := s.req, s.resp
+	start := time.Now()
+
+	err := c.initRequest(req, resp, request, method)
+	if err != nil {
+		return err
+	}
+	req.SetTimeout(0)
+	if setHeaders != nil {
+		setHeaders(req)
+	}
+
+	reqInfo := &RequestInfo{
+		Request:            req,
+		Context:            ctx,
+		resultSuccessCheck: successCheck,
+		stream:             s.conn,
+	}
+
+	var sendErr error
+	send := func(req *RequestInfo, resp *fasthttp.Response) error {
+		sendErr = c.doRequest(req, resp)
+		return sendErr
+	}
+	err = c.attempt(reqInfo, resp, start, send, func() {
+		_ = resp.CloseBodyStream()
+		resp.Reset()
+	})
+	if err == nil && !reqInfo.IsSuccess(resp.StatusCode()) {
+		err = c.decompressResponse(resp)
+		if err == nil && request.ErrorResult != nil && len(resp.Body()) > 0 {
+			err = c.initResult(request.ErrorResult, resp)
+		}
+	}
+	c.streamCompleted(reqInfo, resp, time.Since(start), err)
+	if err != nil && err == sendErr {
+		return &transportError{err: err}
+	}
+	return err
+}
+
+// transportError is an error of the transport, e.g. a failed connection, that sending the request again may not have.
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// streamCompleted calls OnRequestCompleted and OnRequestError once the response headers of a stream are read.
+// While the body is streamed the hooks get a copy of the response with the headers only, so a hook reading
+// the body neither blocks until the stream ends nor consumes it. Bodies of unsuccessful status codes are
+// read before and passed to the hooks.
+func (c *httpClient) streamCompleted(req *RequestInfo, resp *fasthttp.Response, totalTime time.Duration, err error) {
+	if !resp.IsBodyStream() {
+		c.completed(req, resp, totalTime, err)
+		return
+	}
+	headers := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(headers)
+	resp.Header.CopyTo(&headers.Header)
+	c.completed(req, headers, totalTime, err)
+}
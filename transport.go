@@ -0,0 +1,230 @@
+package reqx
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+// Transport sends a prepared request and reads the response into resp.
+// The default transport is a fasthttp.Client built from the ClientOption.
+type Transport interface {
+	Do(req *RequestInfo, resp *fasthttp.Response) error
+}
+
+type TransportFunc func(req *RequestInfo, resp *fasthttp.Response) error
+
+func (f TransportFunc) Do(req *RequestInfo, resp *fasthttp.Response) error {
+	return f(req, resp)
+}
+
+// TransportMiddleware wraps a Transport, e.g. to record, trace or log requests.
+type TransportMiddleware func(next Transport) Transport
+
+type fastHttpTransport struct {
+	client            *fasthttp.Client
+	maxRedirectsCount int
+	dial              fasthttp.DialFunc
+	server            *inmemoryServer
+}
+
+func newFastHttpTransport(opt *ClientOption) *fastHttpTransport {
+	tcpDialer := fasthttp.TCPDialer{
+		Concurrency:      4096,
+		DNSCacheDuration: time.Hour,
+	}
+	dial := tcpDialer.Dial
+	var server *inmemoryServer
+	if opt.Handler != nil {
+		server = newInmemoryServer(opt.Handler)
+		dial = server.dial
+	}
+	maxIdleConnDuration := time.Hour * 1
+	fastHttpClient := &fasthttp.Client{
+		Name:                          opt.UserAgent,
+		ReadTimeout:                   opt.Timeout,
+		WriteTimeout:                  opt.Timeout,
+		MaxIdleConnDuration:           maxIdleConnDuration,
+		NoDefaultUserAgentHeader:      true, // Don't send: User-Agent: fasthttp
+		DisableHeaderNamesNormalizing: true, // If you set the case on your headers correctly you can enable this
+		DisablePathNormalizing:        true,
+		Dial:                          dial,
+		MaxConnsPerHost:               opt.MaxConnsPerHost,
+		TLSConfig:                     opt.TlsConfig,
+	}
+
+	return &fastHttpTransport{
+		client:            fastHttpClient,
+		maxRedirectsCount: opt.MaxRedirectsCount,
+		dial:              dial,
+		server:            server,
+	}
+}
+
+// Close closes idle connections and stops the in-memory server.
+func (t *fastHttpTransport) Close() error {
+	t.client.CloseIdleConnections()
+	if t.server != nil {
+		return t.server.Close()
+	}
+	return nil
+}
+
+func (t *fastHttpTransport) Do(req *RequestInfo, resp *fasthttp.Response) error {
+	if req.stream != nil {
+		return t.doStream(req, resp)
+	}
+	if t.maxRedirectsCount > 0 {
+		return t.client.DoRedirects(req.Request, resp, t.maxRedirectsCount)
+	}
+	return t.client.Do(req.Request, resp)
+}
+
+// doStream sends req on a new connection without read timeout and leaves the response body in resp.BodyStream.
+// The connection is registered in req.stream so it can be closed while the body is read.
+func (t *fastHttpTransport) doStream(req *RequestInfo, resp *fasthttp.Response) error {
+	isTLS := string(req.URI().Scheme()) == "https"
+	hostClient := &fasthttp.HostClient{
+		Addr:                          addMissingPort(string(req.URI().Host()), isTLS),
+		IsTLS:                         isTLS,
+		TLSConfig:                     t.client.TLSConfig,
+		Name:                          t.client.Name,
+		WriteTimeout:                  t.client.WriteTimeout,
+		NoDefaultUserAgentHeader:      true,
+		DisableHeaderNamesNormalizing: true,
+		DisablePathNormalizing:        true,
+		StreamResponseBody:            true,
+		Dial: func(addr string) (net.Conn, error) {
+			conn, err := t.dial(addr)
+			if err != nil {
+				return nil, err
+			}
+			return conn, req.stream.set(conn)
+		},
+	}
+	req.SetConnectionClose()
+	return hostClient.Do(req.Request, resp)
+}
+
+var errStreamClosed = errors.New("reqx: stream closed")
+
+// streamConn is the connection of a streamed response, closed on Close even while it is read.
+type streamConn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+func (s *streamConn) set(conn net.Conn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		_ = conn.Close()
+		return errStreamClosed
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *streamConn) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+func addMissingPort(addr string, isTLS bool) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	if isTLS {
+		return net.JoinHostPort(strings.Trim(addr, "[]"), "443")
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), "80")
+}
+
+// inmemoryServer serves a handler on an in-memory listener until it is closed.
+type inmemoryServer struct {
+	ln     *fasthttputil.InmemoryListener
+	served chan struct{}
+
+	mu    sync.Mutex
+	conns map[*inmemoryConn]struct{}
+}
+
+func newInmemoryServer(handler fasthttp.RequestHandler) *inmemoryServer {
+	s := &inmemoryServer{
+		ln:     fasthttputil.NewInmemoryListener(),
+		served: make(chan struct{}),
+		conns:  map[*inmemoryConn]struct{}{},
+	}
+	// Header names are normalized as by a server on the network, so handlers can peek them in any case,
+	// e.g. the Upgrade headers of WebSocket handshakes or Last-Event-ID.
+	server := &fasthttp.Server{
+		Handler: handler,
+		// Connections closed by the client are expected, e.g. when a stream is cancelled.
+		Logger: quietLogger{},
+		// The worker pool notices a closed server after this duration.
+		MaxIdleWorkerDuration: time.Second,
+	}
+	go func() {
+		defer close(s.served)
+		_ = server.Serve(s.ln)
+	}()
+	return s
+}
+
+func (s *inmemoryServer) dial(addr string) (net.Conn, error) {
+	conn, err := s.ln.Dial()
+	if err != nil {
+		return nil, err
+	}
+	c := &inmemoryConn{Conn: conn, server: s}
+	s.mu.Lock()
+	s.conns[c] = struct{}{}
+	s.mu.Unlock()
+	return c, nil
+}
+
+// Close stops accepting connections, closes the open ones and waits for the server to stop.
+func (s *inmemoryServer) Close() error {
+	err := s.ln.Close()
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = map[*inmemoryConn]struct{}{}
+	s.mu.Unlock()
+	for c := range conns {
+		_ = c.Conn.Close()
+	}
+	<-s.served
+	return err
+}
+
+// inmemoryConn reports itself as a TLS connection so https URLs are sent to the handler unencrypted.
+type inmemoryConn struct {
+	net.Conn
+	server *inmemoryServer
+}
+
+func (c *inmemoryConn) Handshake() error {
+	return nil
+}
+
+func (c *inmemoryConn) Close() error {
+	c.server.mu.Lock()
+	delete(c.server.conns, c)
+	c.server.mu.Unlock()
+	return c.Conn.Close()
+}
+
+type quietLogger struct{}
+
+func (quietLogger) Printf(string, ...interface{}) {}
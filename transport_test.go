@@ -0,0 +1,135 @@
+package reqx_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func Test_WithHTTPHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+		_, _ = fmt.Fprintln(w, ToJsonString(Response{
+			Origin: fmt.Sprintf("%s %s %s %s", r.Method, r.Host, r.Header.Get("X-Request-Id"), body),
+		}))
+	})
+
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithHTTPHandler(mux),
+	)
+
+	for _, baseURL := range []string{"http://api.local", "https://api.local"} {
+		result := &Response{}
+		resp, err := client.Post(&reqx.Request{
+			URL:     baseURL + "/users",
+			Headers: reqx.Headers{"X-Request-Id": "1"},
+			Data:    &Data{Name: "Reqx"},
+			Result:  result,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || result.Origin != `POST api.local 1 {"name":"Reqx"}` {
+			t.Errorf("Test_WithHTTPHandler Error: %d %s", resp.StatusCode, result.Origin)
+		}
+	}
+}
+
+func Test_WithHandler(t *testing.T) {
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithBaseURL("http://api.local"),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(http.StatusAccepted)
+			ctx.SetBodyString(fmt.Sprintf(`{"origin":"%s"}`, ctx.QueryArgs().Peek("name")))
+		}),
+	)
+
+	result := &Response{}
+	resp, err := client.Get(&reqx.Request{
+		URL:    "/users?name=reqx",
+		Result: result,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusAccepted || result.Origin != "reqx" {
+		t.Errorf("Test_WithHandler Error: %d %s", resp.StatusCode, result.Origin)
+	}
+}
+
+func Test_WithHandler_HeaderNames(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetBody(ctx.Request.Header.Peek("X-Request-Id"))
+	}))
+	defer reqx.Close(client)
+
+	var result []byte
+	_, err := client.Get(&reqx.Request{
+		URL:     "http://api.local/",
+		Headers: reqx.Headers{"x-request-id": "1"},
+		Result:  &result,
+	})
+	if err != nil || string(result) != "1" {
+		t.Errorf("Test_WithHandler_HeaderNames Error: %q, %v", result, err)
+	}
+}
+
+func inmemoryServerGoroutines() int {
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	count := 0
+	for _, g := range strings.Split(string(buf), "\n\n") {
+		if strings.Contains(g, "fasthttp.(*Server)") || strings.Contains(g, "fasthttp.(*workerPool)") {
+			count++
+		}
+	}
+	return count
+}
+
+func Test_WithHandler_Close(t *testing.T) {
+	before := inmemoryServerGoroutines()
+
+	var clients []reqx.Client
+	for i := 0; i < 20; i++ {
+		client := reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {}))
+		_, err := client.Get(&reqx.Request{URL: "http://api.local/"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, client)
+	}
+	if inmemoryServerGoroutines() <= before {
+		t.Fatal("Test_WithHandler_Close Error: no server goroutines")
+	}
+
+	for _, client := range clients {
+		err := reqx.Close(client)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for inmemoryServerGoroutines() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := inmemoryServerGoroutines(); n > before {
+		t.Errorf("Test_WithHandler_Close Error: %d server goroutines left, want %d", n, before)
+	}
+
+	_, err := clients[0].Get(&reqx.Request{URL: "http://api.local/"})
+	if err == nil {
+		t.Error("Test_WithHandler_Close Error: request after Close succeeded")
+	}
+}
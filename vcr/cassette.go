@@ -0,0 +1,120 @@
+package vcr
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	gojson "github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	cassetteVersion = 1
+	bodyBase64      = "base64"
+)
+
+// Cassette is the file format of recorded interactions. Files with a .yaml or .yml extension
+// are stored as YAML, others as JSON.
+type Cassette struct {
+	Version      int            `json:"version" yaml:"version"`
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request" yaml:"request"`
+	Response Response `json:"response" yaml:"response"`
+}
+
+type Request struct {
+	Method       string              `json:"method" yaml:"method"`
+	URL          string              `json:"url" yaml:"url"`
+	Headers      map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string              `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string              `json:"bodyEncoding,omitempty" yaml:"bodyEncoding,omitempty"`
+}
+
+type Response struct {
+	StatusCode   int                 `json:"statusCode" yaml:"statusCode"`
+	Headers      map[string][]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body         string              `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string              `json:"bodyEncoding,omitempty" yaml:"bodyEncoding,omitempty"`
+}
+
+func (r *Request) SetBody(body []byte) {
+	r.Body, r.BodyEncoding = encodeBody(body)
+}
+
+func (r *Request) GetBody() ([]byte, error) {
+	return decodeBody(r.Body, r.BodyEncoding)
+}
+
+func (r *Response) SetBody(body []byte) {
+	r.Body, r.BodyEncoding = encodeBody(body)
+}
+
+func (r *Response) GetBody() ([]byte, error) {
+	return decodeBody(r.Body, r.BodyEncoding)
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cassette := &Cassette{}
+	if isYAML(path) {
+		err = yaml.Unmarshal(data, cassette)
+	} else {
+		err = gojson.Unmarshal(data, cassette)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cassette, nil
+}
+
+// Save writes the cassette to path, creating parent directories as needed.
+func (c *Cassette) Save(path string) error {
+	c.Version = cassetteVersion
+
+	var data []byte
+	var err error
+	if isYAML(path) {
+		data, err = yaml.Marshal(c)
+	} else {
+		data, err = gojson.MarshalIndent(c, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+func isYAML(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), bodyBase64
+}
+
+func decodeBody(body string, encoding string) ([]byte, error) {
+	if encoding == bodyBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
@@ -0,0 +1,257 @@
+package vcr
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves responses from the cassette and never touches the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and overwrites the cassette.
+	ModeRecord
+	// ModeReplayOrRecord replays matching interactions and records the missing ones.
+	ModeReplayOrRecord
+)
+
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactHeaders are redacted when Options.RedactHeaders is nil.
+var DefaultRedactHeaders = []string{
+	reqx.HeaderAuthorization,
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+}
+
+var ErrInteractionNotFound = errors.New("vcr: no recorded interaction matches request")
+
+type Options struct {
+	Mode Mode
+	// MatchHeaders are request headers that must be equal in addition to method and URL.
+	MatchHeaders []string
+	// MatchBody also compares request bodies.
+	MatchBody bool
+	// Matcher replaces the default matching on method, URL, MatchHeaders and MatchBody.
+	Matcher func(req *Request, recorded *Request) bool
+	// RedactHeaders are request and response headers replaced with RedactedValue before saving.
+	// Defaults to DefaultRedactHeaders; use an empty slice to keep all headers.
+	RedactHeaders []string
+	// RedactQueryParams are query parameters replaced with RedactedValue before saving.
+	RedactQueryParams []string
+	// BeforeSave can modify an interaction before it is saved, e.g. to scrub tokens from bodies.
+	BeforeSave func(interaction *Interaction)
+}
+
+// Recorder records reqx traffic to a cassette file and replays it.
+// Use it with reqx.WithTransportMiddleware(recorder.Middleware). Streamed responses, such as SSE,
+// and WebSocket handshakes are passed through without being recorded.
+type Recorder struct {
+	path string
+	opts Options
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New returns a recorder for the cassette at path. In ModeReplay the cassette must exist.
+func New(path string, opts Options) (*Recorder, error) {
+	if opts.RedactHeaders == nil {
+		opts.RedactHeaders = DefaultRedactHeaders
+	}
+
+	r := &Recorder{
+		path:     path,
+		opts:     opts,
+		cassette: &Cassette{},
+	}
+
+	if opts.Mode != ModeRecord {
+		cassette, err := LoadCassette(path)
+		if err != nil && (opts.Mode == ModeReplay || !errors.Is(err, fs.ErrNotExist)) {
+			return nil, err
+		}
+		if cassette != nil {
+			r.cassette = cassette
+		}
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Cassette returns the interactions loaded or recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette
+}
+
+func (r *Recorder) Middleware(next reqx.Transport) reqx.Transport {
+	return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+		if strings.EqualFold(string(req.Header.Peek("Upgrade")), "websocket") {
+			// A replayed handshake has no connection to upgrade.
+			return next.Do(req, resp)
+		}
+		request := r.newRequest(req)
+
+		if r.opts.Mode != ModeRecord {
+			interaction := r.find(request)
+			if interaction != nil {
+				return replay(interaction, resp)
+			}
+			if r.opts.Mode == ModeReplay {
+				return fmt.Errorf("%w: %s %s", ErrInteractionNotFound, request.Method, request.URL)
+			}
+		}
+
+		err := next.Do(req, resp)
+		if err != nil {
+			return err
+		}
+		if resp.IsBodyStream() {
+			// Streamed bodies, such as SSE, are read by the caller and not recorded.
+			return nil
+		}
+		return r.record(request, resp)
+	})
+}
+
+func (r *Recorder) newRequest(req *reqx.RequestInfo) *Request {
+	request := &Request{
+		Method:  string(req.Header.Method()),
+		URL:     r.redactURL(req.URI()),
+		Headers: map[string][]string{},
+	}
+	req.Header.VisitAll(func(key, value []byte) {
+		request.Headers[string(key)] = append(request.Headers[string(key)], r.redactHeader(string(key), string(value)))
+	})
+	request.SetBody(req.Body())
+	return request
+}
+
+// find returns the first unused matching interaction, or the last matching one when all were used.
+func (r *Recorder) find(request *Request) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found *Interaction
+	for i, interaction := range r.cassette.Interactions {
+		if !r.matches(request, &interaction.Request) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction
+		}
+		found = interaction
+	}
+	return found
+}
+
+func (r *Recorder) matches(request *Request, recorded *Request) bool {
+	if r.opts.Matcher != nil {
+		return r.opts.Matcher(request, recorded)
+	}
+	if request.Method != recorded.Method || request.URL != recorded.URL {
+		return false
+	}
+	for _, key := range r.opts.MatchHeaders {
+		if strings.Join(headerValues(request.Headers, key), ",") != strings.Join(headerValues(recorded.Headers, key), ",") {
+			return false
+		}
+	}
+	if r.opts.MatchBody && (request.Body != recorded.Body || request.BodyEncoding != recorded.BodyEncoding) {
+		return false
+	}
+	return true
+}
+
+func (r *Recorder) record(request *Request, resp *fasthttp.Response) error {
+	interaction := &Interaction{
+		Request: *request,
+		Response: Response{
+			StatusCode: resp.StatusCode(),
+			Headers:    map[string][]string{},
+		},
+	}
+	resp.Header.VisitAll(func(key, value []byte) {
+		interaction.Response.Headers[string(key)] = append(interaction.Response.Headers[string(key)], r.redactHeader(string(key), string(value)))
+	})
+	interaction.Response.SetBody(resp.Body())
+
+	if r.opts.BeforeSave != nil {
+		r.opts.BeforeSave(interaction)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used = append(r.used, true)
+	return r.cassette.Save(r.path)
+}
+
+func replay(interaction *Interaction, resp *fasthttp.Response) error {
+	body, err := interaction.Response.GetBody()
+	if err != nil {
+		return err
+	}
+
+	resp.SetStatusCode(interaction.Response.StatusCode)
+	for key, values := range interaction.Response.Headers {
+		if strings.EqualFold(key, fasthttp.HeaderContentLength) {
+			continue
+		}
+		for _, value := range values {
+			resp.Header.Add(key, value)
+		}
+	}
+	resp.SetBody(body)
+	return nil
+}
+
+func (r *Recorder) redactHeader(key string, value string) string {
+	for _, redact := range r.opts.RedactHeaders {
+		if strings.EqualFold(key, redact) {
+			return RedactedValue
+		}
+	}
+	return value
+}
+
+func (r *Recorder) redactURL(uri *fasthttp.URI) string {
+	if len(r.opts.RedactQueryParams) == 0 {
+		return uri.String()
+	}
+
+	u := fasthttp.AcquireURI()
+	defer fasthttp.ReleaseURI(u)
+	uri.CopyTo(u)
+
+	args := u.QueryArgs()
+	for _, key := range r.opts.RedactQueryParams {
+		if args.Has(key) {
+			args.Set(key, RedactedValue)
+		}
+	}
+	return u.String()
+}
+
+func headerValues(headers map[string][]string, key string) []string {
+	for k, values := range headers {
+		if strings.EqualFold(k, key) {
+			return values
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package vcr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+
+	"github.com/dreamph/reqx"
+	"github.com/dreamph/reqx/vcr"
+)
+
+type Data struct {
+	Name string `json:"name"`
+}
+
+func newServer(hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeJson)
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/binary" {
+			_, _ = w.Write([]byte{0xff, 0x00, 0xfe})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&Data{Name: r.Method + " " + string(body)})
+	}))
+}
+
+func newClient(t *testing.T, path string, opts vcr.Options) reqx.Client {
+	recorder, err := vcr.New(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithHeaders(reqx.Headers{reqx.HeaderAuthorization: "Bearer secret"}),
+		reqx.WithTransportMiddleware(recorder.Middleware),
+	)
+}
+
+func Test_RecordAndReplay(t *testing.T) {
+	for _, name := range []string{"cassette.yaml", "cassette.json"} {
+		t.Run(name, func(t *testing.T) {
+			var hits int32
+			ts := newServer(&hits)
+			path := filepath.Join(t.TempDir(), "fixtures", name)
+			opts := vcr.Options{
+				MatchBody:         true,
+				RedactQueryParams: []string{"apiKey"},
+			}
+
+			send := func(client reqx.Client) (string, []byte) {
+				result := &Data{}
+				_, err := client.Post(&reqx.Request{
+					URL:    ts.URL + "/users?apiKey=123",
+					Data:   &Data{Name: "reqx"},
+					Result: result,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				var binary []byte
+				_, err = client.Get(&reqx.Request{
+					URL:    ts.URL + "/binary",
+					Result: &binary,
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return result.Name, binary
+			}
+
+			opts.Mode = vcr.ModeRecord
+			recorded, recordedBinary := send(newClient(t, path, opts))
+			ts.Close()
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "secret") || strings.Contains(string(data), "apiKey=123") {
+				t.Errorf("Test_RecordAndReplay Error: cassette is not redacted\n%s", data)
+			}
+
+			opts.Mode = vcr.ModeReplay
+			client := newClient(t, path, opts)
+			replayed, replayedBinary := send(client)
+			if replayed != recorded || replayed != `POST {"name":"reqx"}` || string(replayedBinary) != string(recordedBinary) {
+				t.Errorf("Test_RecordAndReplay Error: replayed %q %v, recorded %q %v", replayed, replayedBinary, recorded, recordedBinary)
+			}
+			if hits != 2 {
+				t.Errorf("Test_RecordAndReplay Error: %d server hits", hits)
+			}
+
+			_, err = client.Post(&reqx.Request{
+				URL:  ts.URL + "/users?apiKey=123",
+				Data: &Data{Name: "other"},
+			})
+			if !errors.Is(err, vcr.ErrInteractionNotFound) {
+				t.Errorf("Test_RecordAndReplay Error: %v", err)
+			}
+		})
+	}
+}
+
+func Test_ReplayOrRecord(t *testing.T) {
+	var hits int32
+	ts := newServer(&hits)
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	_, err := vcr.New(path, vcr.Options{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Test_ReplayOrRecord Error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		recorder, err := vcr.New(path, vcr.Options{Mode: vcr.ModeReplayOrRecord})
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := reqx.New(reqx.WithTransportMiddleware(recorder.Middleware))
+		for j := 0; j < 2; j++ {
+			_, err = client.Get(&reqx.Request{URL: ts.URL + "/users"})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if len(recorder.Cassette().Interactions) != 1 {
+			t.Errorf("Test_ReplayOrRecord Error: %d interactions", len(recorder.Cassette().Interactions))
+		}
+	}
+	if hits != 1 {
+		t.Errorf("Test_ReplayOrRecord Error: %d server hits", hits)
+	}
+}
+
+func Test_Record_SSE(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(reqx.HeaderContentType, reqx.HeaderContentTypeEventStream)
+		_, _ = w.Write([]byte("data: hello\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	recorder, err := vcr.New(filepath.Join(t.TempDir(), "cassette.yaml"), vcr.Options{Mode: vcr.ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithTransportMiddleware(recorder.Middleware),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := reqx.SSE(ctx, client, &reqx.Request{URL: ts.URL + "/events"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := <-events
+	cancel()
+	for range events {
+	}
+	if event.Data != "hello" || len(recorder.Cassette().Interactions) != 0 {
+		t.Errorf("Test_Record_SSE Error: event %+v, %d interactions", event, len(recorder.Cassette().Interactions))
+	}
+}
+
+func Test_Record_WebSocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer ts.Close()
+
+	recorder, err := vcr.New(filepath.Join(t.TempDir(), "cassette.yaml"), vcr.Options{Mode: vcr.ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := reqx.New(
+		reqx.WithTimeout(10*time.Second),
+		reqx.WithTransportMiddleware(recorder.Middleware),
+	)
+
+	conn, err := reqx.WebSocket(context.Background(), client, &reqx.Request{URL: ts.URL + "/chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if len(recorder.Cassette().Interactions) != 0 {
+		t.Errorf("Test_Record_WebSocket Error: %d interactions", len(recorder.Cassette().Interactions))
+	}
+}
@@ -0,0 +1,356 @@
+package reqx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	WebSocketTextMessage   = websocket.TextMessage
+	WebSocketBinaryMessage = websocket.BinaryMessage
+
+	webSocketCloseTimeout = time.Second
+)
+
+var ErrWebSocketHandshake = errors.New("reqx: websocket handshake failed")
+
+type WebSocketOption struct {
+	Subprotocols []string
+	// PingInterval sends a ping at this interval, 0 disables pings.
+	PingInterval time.Duration
+	// PongWait fails reads when no pong arrives within it, 2*PingInterval by default.
+	PongWait          time.Duration
+	ReadLimit         int64
+	EnableCompression bool
+}
+
+type WebSocketOptions func(opts *WebSocketOption)
+
+func WithWebSocketSubprotocols(subprotocols ...string) WebSocketOptions {
+	return func(opts *WebSocketOption) {
+		opts.Subprotocols = append(opts.Subprotocols, subprotocols...)
+	}
+}
+
+// WithWebSocketPing sends pings every interval and fails reads when no pong arrives within pongWait.
+func WithWebSocketPing(interval time.Duration, pongWait time.Duration) WebSocketOptions {
+	return func(opts *WebSocketOption) {
+		opts.PingInterval = interval
+		opts.PongWait = pongWait
+	}
+}
+
+// WithWebSocketReadLimit limits the size of received messages in bytes.
+func WithWebSocketReadLimit(limit int64) WebSocketOptions {
+	return func(opts *WebSocketOption) {
+		opts.ReadLimit = limit
+	}
+}
+
+func WithWebSocketCompression() WebSocketOptions {
+	return func(opts *WebSocketOption) {
+		opts.EnableCompression = true
+	}
+}
+
+// webSocketTransport performs the handshakes of WebSocket and passes other requests to next.
+// It wraps the default transport, so handshakes pass the logger and the transport middlewares.
+type webSocketTransport struct {
+	next      Transport
+	dialer    *websocket.Dialer
+	userAgent string
+}
+
+// newWebSocketTransport dials with the dial function and TLS config of the default transport.
+func newWebSocketTransport(opt *ClientOption, next Transport) *webSocketTransport {
+	dialer := &websocket.Dialer{
+		TLSClientConfig: opt.TlsConfig,
+	}
+	if t, ok := next.(*fastHttpTransport); ok {
+		dial := func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			return t.dial(addr)
+		}
+		dialer.NetDialContext = dial
+		if opt.Handler != nil {
+			// In-memory connections skip TLS.
+			dialer.NetDialTLSContext = dial
+		}
+	}
+	return &webSocketTransport{
+		next:      next,
+		dialer:    dialer,
+		userAgent: opt.UserAgent,
+	}
+}
+
+func (t *webSocketTransport) Do(req *RequestInfo, resp *fasthttp.Response) error {
+	if req.webSocket == nil {
+		return t.next.Do(req, resp)
+	}
+
+	dialer := *t.dialer
+	dialer.Subprotocols = req.webSocket.opt.Subprotocols
+	dialer.EnableCompression = req.webSocket.opt.EnableCompression
+	dialer.HandshakeTimeout = req.webSocket.timeout
+
+	conn, httpResp, err := dialer.DialContext(req.Context, webSocketURL(req.URI()), t.header(req.Request))
+	if httpResp != nil {
+		setWebSocketResponse(resp, httpResp)
+	}
+	if err != nil && (httpResp == nil || httpResp.StatusCode == http.StatusSwitchingProtocols) {
+		return err
+	}
+	req.webSocket.setConn(conn)
+	return nil
+}
+
+// header returns the request headers without those set by the handshake.
+func (t *webSocketTransport) header(req *fasthttp.Request) http.Header {
+	header := http.Header{}
+	req.Header.VisitAll(func(key, value []byte) {
+		name := http.CanonicalHeaderKey(string(key))
+		switch name {
+		case "Upgrade", "Connection", "Sec-Websocket-Key", "Sec-Websocket-Version", "Sec-Websocket-Extensions",
+			fasthttp.HeaderContentType, fasthttp.HeaderContentLength:
+			return
+		}
+		header.Add(name, string(value))
+	})
+	if header.Get(fasthttp.HeaderUserAgent) == "" && t.userAgent != "" {
+		header.Set(fasthttp.HeaderUserAgent, t.userAgent)
+	}
+	return header
+}
+
+// webSocketHandshake marks a request sent by WebSocket and keeps the connection of its handshake.
+type webSocketHandshake struct {
+	opt     *WebSocketOption
+	timeout time.Duration
+	conn    *websocket.Conn
+}
+
+// setConn keeps conn and closes the connection of an earlier handshake, e.g. when a middleware sends the request again.
+func (h *webSocketHandshake) setConn(conn *websocket.Conn) {
+	if h.conn != nil {
+		_ = h.conn.Close()
+	}
+	h.conn = conn
+}
+
+// WebSocketClient is implemented by clients that open WebSocket connections, such as the clients of New.
+// Wrappers of a Client implement it to support WebSocket.
+type WebSocketClient interface {
+	WebSocket(ctx context.Context, request *Request, opts ...WebSocketOptions) (*WebSocketConn, error)
+}
+
+// WebSocket performs the Upgrade handshake for request with the base URL, headers, authentication and signing
+// of the client. http and https URLs are sent as ws and wss. A failed handshake returns ErrWebSocketHandshake
+// and decodes the response body into request.ErrorResult. The handshake is sent through the logger and the
+// transport middlewares with the Upgrade header set, and the request hooks of the client run for it.
+// It returns ErrUnsupportedClient when client does not implement WebSocketClient.
+func WebSocket(ctx context.Context, client Client, request *Request, opts ...WebSocketOptions) (*WebSocketConn, error) {
+	c, ok := client.(WebSocketClient)
+	if !ok {
+		return nil, ErrUnsupportedClient
+	}
+	return c.WebSocket(ctx, request, opts...)
+}
+
+func (c *httpClient) WebSocket(ctx context.Context, request *Request, opts ...WebSocketOptions) (*WebSocketConn, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	opt := &WebSocketOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	start := time.Now()
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer func() {
+		fasthttp.ReleaseRequest(req)
+		fasthttp.ReleaseResponse(resp)
+	}()
+
+	handshakeRequest := *request
+	handshakeRequest.Data = nil
+	err := c.initRequest(req, resp, &handshakeRequest, http.MethodGet)
+	if err != nil {
+		return nil, err
+	}
+	if string(req.Header.Peek(HeaderAcceptEncoding)) == DefaultAcceptEncoding {
+		req.Header.Del(HeaderAcceptEncoding)
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set(fasthttp.HeaderConnection, "Upgrade")
+
+	handshake := &webSocketHandshake{
+		opt:     opt,
+		timeout: c.timeout,
+	}
+	if request.Timeout > 0 {
+		handshake.timeout = request.Timeout
+	}
+	reqInfo := &RequestInfo{
+		Request: req,
+		Context: ctx,
+		resultSuccessCheck: func(statusCode int) bool {
+			return statusCode == http.StatusSwitchingProtocols
+		},
+		webSocket: handshake,
+	}
+
+	err = c.attempt(reqInfo, resp, start, c.doRequest, resp.Reset)
+	if err == nil && !reqInfo.IsSuccess(resp.StatusCode()) && request.ErrorResult != nil && len(resp.Body()) > 0 {
+		err = c.initResult(request.ErrorResult, resp)
+	}
+	if err == nil && (handshake.conn == nil || !reqInfo.IsSuccess(resp.StatusCode())) {
+		// A middleware may answer without sending the handshake.
+		err = fmt.Errorf("%w: status %d", ErrWebSocketHandshake, resp.StatusCode())
+	}
+	c.completed(reqInfo, resp, time.Since(start), err)
+	if err != nil {
+		handshake.setConn(nil)
+		return nil, err
+	}
+	return newWebSocketConn(handshake.conn, c.codecs, opt), nil
+}
+
+// setWebSocketResponse copies the handshake response httpResp into resp.
+func setWebSocketResponse(resp *fasthttp.Response, httpResp *http.Response) {
+	resp.SetStatusCode(httpResp.StatusCode)
+	for key, values := range httpResp.Header {
+		for _, v := range values {
+			resp.Header.Add(key, v)
+		}
+	}
+	body, _ := io.ReadAll(httpResp.Body)
+	resp.SetBody(body)
+}
+
+func webSocketURL(uri *fasthttp.URI) string {
+	u := uri.String()
+	switch string(uri.Scheme()) {
+	case "http":
+		return "ws" + strings.TrimPrefix(u, "http")
+	case "https":
+		return "wss" + strings.TrimPrefix(u, "https")
+	}
+	return u
+}
+
+// WebSocketConn is a message-oriented WebSocket connection. Writes are safe for concurrent use,
+// reads are not. Pings of the peer are answered and its close frames are echoed by ReadMessage.
+type WebSocketConn struct {
+	conn      *websocket.Conn
+	codecs    *codecs
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newWebSocketConn(conn *websocket.Conn, codecs *codecs, opt *WebSocketOption) *WebSocketConn {
+	c := &WebSocketConn{
+		conn:   conn,
+		codecs: codecs,
+		done:   make(chan struct{}),
+	}
+	if opt.ReadLimit > 0 {
+		conn.SetReadLimit(opt.ReadLimit)
+	}
+	if opt.PingInterval > 0 {
+		pongWait := opt.PongWait
+		if pongWait <= 0 {
+			pongWait = 2 * opt.PingInterval
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		go c.ping(opt.PingInterval)
+	}
+	return c
+}
+
+func (c *WebSocketConn) ping(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(interval))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Subprotocol returns the subprotocol selected by the server.
+func (c *WebSocketConn) Subprotocol() string {
+	return c.conn.Subprotocol()
+}
+
+// ReadMessage returns the next text or binary message. A close frame of the peer returns a *websocket.CloseError.
+func (c *WebSocketConn) ReadMessage() (messageType int, data []byte, err error) {
+	return c.conn.ReadMessage()
+}
+
+func (c *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+// ReadJSON reads the next message into v with the JSON codec of the client.
+func (c *WebSocketConn) ReadJSON(v interface{}) error {
+	_, data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return c.codecs.json.Unmarshal(data, v)
+}
+
+// WriteJSON sends v as a text message encoded with the JSON codec of the client.
+func (c *WebSocketConn) WriteJSON(v interface{}) error {
+	data, err := c.codecs.json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(WebSocketTextMessage, data)
+}
+
+// Close sends a normal closure frame and closes the connection.
+func (c *WebSocketConn) Close() error {
+	return c.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+// CloseWithCode sends a close frame with code and reason and closes the connection.
+func (c *WebSocketConn) CloseWithCode(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(webSocketCloseTimeout))
+		err = c.conn.Close()
+	})
+	return err
+}
+
+// Conn returns the underlying connection.
+func (c *WebSocketConn) Conn() *websocket.Conn {
+	return c.conn
+}
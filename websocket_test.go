@@ -0,0 +1,180 @@
+package reqx_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+func Test_WebSocket(t *testing.T) {
+	pings := make(chan struct{}, 10)
+	upgrader := websocket.FastHTTPUpgrader{Subprotocols: []string{"chat.v1"}}
+	client := reqx.New(
+		reqx.WithBaseURL("https://api.local"),
+		reqx.WithHeaders(reqx.Headers{"X-Tenant": "reqx"}),
+		reqx.WithAuthenticator(reqx.BearerAuth("token")),
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Request.Header.Peek(reqx.HeaderAuthorization)) != "Bearer token" || string(ctx.Request.Header.Peek("X-Tenant")) != "reqx" {
+				ctx.SetStatusCode(http.StatusUnauthorized)
+				ctx.SetContentType(reqx.HeaderContentTypeJson)
+				ctx.SetBodyString(`{"name":"unauthorized"}`)
+				return
+			}
+			err := upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+				defer conn.Close()
+				conn.SetPingHandler(func(data string) error {
+					pings <- struct{}{}
+					return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+				})
+				for {
+					messageType, data, err := conn.ReadMessage()
+					if err != nil {
+						return
+					}
+					err = conn.WriteMessage(messageType, data)
+					if err != nil {
+						return
+					}
+				}
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}),
+	)
+
+	conn, err := reqx.WebSocket(context.Background(), client, &reqx.Request{URL: "/chat"},
+		reqx.WithWebSocketSubprotocols("chat.v1"),
+		reqx.WithWebSocketPing(20*time.Millisecond, time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Subprotocol() != "chat.v1" {
+		t.Errorf("Test_WebSocket Error: subprotocol %q", conn.Subprotocol())
+	}
+
+	err = conn.WriteJSON(&Data{Name: "reqx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var echo Data
+	err = conn.ReadJSON(&echo)
+	if err != nil || echo.Name != "reqx" {
+		t.Errorf("Test_WebSocket Error: %+v, %v", echo, err)
+	}
+
+	select {
+	case <-pings:
+	case <-time.After(time.Second):
+		t.Error("Test_WebSocket Error: no ping")
+	}
+
+	err = conn.Close()
+	if err != nil {
+		t.Error(err)
+	}
+
+	var errorResult Data
+	_, err = reqx.WebSocket(context.Background(), reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusUnauthorized)
+		ctx.SetContentType(reqx.HeaderContentTypeJson)
+		ctx.SetBodyString(`{"name":"unauthorized"}`)
+	})), &reqx.Request{URL: "http://api.local/chat", ErrorResult: &errorResult})
+	if !errors.Is(err, reqx.ErrWebSocketHandshake) || errorResult.Name != "unauthorized" {
+		t.Errorf("Test_WebSocket Error: %v %+v", err, errorResult)
+	}
+}
+
+func Test_WebSocket_Hooks(t *testing.T) {
+	var statuses []int
+	var failed int
+	upgrader := websocket.FastHTTPUpgrader{}
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			if string(ctx.Path()) != "/chat" {
+				ctx.SetStatusCode(http.StatusNotFound)
+				ctx.SetContentType(reqx.HeaderContentTypeJson)
+				ctx.SetBodyString(`{"name":`)
+				return
+			}
+			_ = upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+				_, _, _ = conn.ReadMessage()
+			})
+		}),
+		reqx.WithOnRequestCompleted(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			statuses = append(statuses, resp.StatusCode())
+		}),
+		reqx.WithOnRequestError(func(req *reqx.RequestInfo, resp *reqx.ResponseInfo) {
+			failed++
+		}),
+	)
+
+	conn, err := reqx.WebSocket(context.Background(), client, &reqx.Request{URL: "http://api.local/chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	var errorResult Data
+	_, err = reqx.WebSocket(context.Background(), client, &reqx.Request{URL: "http://api.local/missing", ErrorResult: &errorResult})
+	if err == nil || errors.Is(err, reqx.ErrWebSocketHandshake) {
+		t.Errorf("Test_WebSocket_Hooks Error: %v", err)
+	}
+	_, err = reqx.WebSocket(context.Background(), client, &reqx.Request{URL: "http://api.local/missing"})
+	if !errors.Is(err, reqx.ErrWebSocketHandshake) {
+		t.Errorf("Test_WebSocket_Hooks Error: %v", err)
+	}
+
+	// The hooks also run when the error body cannot be decoded.
+	if len(statuses) != 3 || statuses[0] != http.StatusSwitchingProtocols || statuses[2] != http.StatusNotFound || failed != 2 {
+		t.Errorf("Test_WebSocket_Hooks Error: completed %v, %d failed", statuses, failed)
+	}
+}
+
+func Test_WebSocket_Middleware(t *testing.T) {
+	var handshakes []string
+	upgrader := websocket.FastHTTPUpgrader{}
+	client := reqx.New(
+		reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+			_ = upgrader.Upgrade(ctx, func(conn *websocket.Conn) {
+				_, _, _ = conn.ReadMessage()
+			})
+		}),
+		reqx.WithTransportMiddleware(func(next reqx.Transport) reqx.Transport {
+			return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+				err := next.Do(req, resp)
+				handshakes = append(handshakes, fmt.Sprintf("%s %d", req.Header.Peek("Upgrade"), resp.StatusCode()))
+				return err
+			})
+		}),
+	)
+
+	conn, err := reqx.WebSocket(context.Background(), client, &reqx.Request{URL: "http://api.local/chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if len(handshakes) != 1 || handshakes[0] != "websocket 101" {
+		t.Errorf("Test_WebSocket_Middleware Error: %q", handshakes)
+	}
+
+	replay := reqx.New(reqx.WithTransportMiddleware(func(next reqx.Transport) reqx.Transport {
+		return reqx.TransportFunc(func(req *reqx.RequestInfo, resp *fasthttp.Response) error {
+			resp.SetStatusCode(http.StatusSwitchingProtocols)
+			return nil
+		})
+	}))
+	_, err = reqx.WebSocket(context.Background(), replay, &reqx.Request{URL: "http://api.local/chat"})
+	if !errors.Is(err, reqx.ErrWebSocketHandshake) {
+		t.Errorf("Test_WebSocket_Middleware Error: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package reqx
+
+import (
+	"encoding/xml"
+)
+
+var (
+	HeaderContentTypeXml     = "application/xml"
+	HeaderContentTypeTextXml = "text/xml"
+)
+
+// XML is a request body marshaled with encoding/xml and sent as application/xml.
+type XML struct {
+	Data interface{}
+}
+
+type xmlCodec struct{}
+
+// NewXmlCodec returns a Codec for application/xml using encoding/xml. Marshal prepends the XML header.
+func NewXmlCodec() Codec {
+	return xmlCodec{}
+}
+
+func (xmlCodec) ContentType() string {
+	return HeaderContentTypeXml
+}
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
@@ -0,0 +1,76 @@
+package reqx_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+
+	"github.com/dreamph/reqx"
+)
+
+type Transfer struct {
+	XMLName xml.Name `xml:"transfer"`
+	Account string   `xml:"account"`
+	Amount  int      `xml:"amount"`
+}
+
+type TransferResult struct {
+	XMLName xml.Name `xml:"result"`
+	ID      string   `xml:"id,attr"`
+}
+
+type Fault struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Code    string   `xml:"Body>Fault>faultcode"`
+}
+
+func Test_XML(t *testing.T) {
+	client := reqx.New(reqx.WithHandler(func(ctx *fasthttp.RequestCtx) {
+		if string(ctx.Request.Header.ContentType()) != reqx.HeaderContentTypeXml {
+			t.Errorf("Test_XML Error: content type %s", ctx.Request.Header.ContentType())
+		}
+		var transfer Transfer
+		err := xml.Unmarshal(ctx.Request.Body(), &transfer)
+		if err != nil {
+			t.Error(err)
+		}
+		if transfer.Amount <= 0 {
+			ctx.SetStatusCode(http.StatusInternalServerError)
+			ctx.SetContentType("application/soap+xml; charset=utf-8")
+			ctx.SetBodyString(`<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><soap:Fault><faultcode>soap:Client</faultcode></soap:Fault></soap:Body></soap:Envelope>`)
+			return
+		}
+		ctx.SetContentType(reqx.HeaderContentTypeTextXml)
+		ctx.SetBodyString(`<?xml version="1.0"?><result id="` + transfer.Account + `-1"></result>`)
+	}))
+
+	var result TransferResult
+	var fault Fault
+	resp, err := client.Post(&reqx.Request{
+		URL:         "http://bank.local/transfers",
+		Data:        reqx.XML{Data: &Transfer{Account: "acc", Amount: 100}},
+		Result:      &result,
+		ErrorResult: &fault,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || result.ID != "acc-1" {
+		t.Errorf("Test_XML Error: %d %+v", resp.StatusCode, result)
+	}
+
+	resp, err = client.Post(&reqx.Request{
+		URL:         "http://bank.local/transfers",
+		Data:        &reqx.XML{Data: &Transfer{Account: "acc"}},
+		Result:      &result,
+		ErrorResult: &fault,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || fault.Code != "soap:Client" {
+		t.Errorf("Test_XML Error: %d %+v", resp.StatusCode, fault)
+	}
+}